@@ -3,8 +3,14 @@
 package cheapRuler
 
 import (
+	"container/heap"
+	"encoding/json"
 	"errors"
 	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
 )
 
 // CheapRuler is the interface implemented by ruler objects.
@@ -20,18 +26,36 @@ type CheapRuler interface {
 	LineDistance(l Line) float64
 	LineSlice(start Point, end Point, l Line) Line
 	LineSliceAlong(start float64, stop float64, l Line) Line
-	Offset(p Point, dx float64, dy float64) float64
+	Offset(p Point, dx float64, dy float64) Point
 	PointOnLine(l Line, p Point) PointOnLine
 }
 
+// Ruler implements CheapRuler.
+var _ CheapRuler = Ruler{}
+
 // Ruler is the type of objects returned when using NewRuler
 type Ruler struct {
-	kx, ky float64
+	kx, ky  float64
+	lat     float64
+	unit    string
+	kmScale float64
 }
 
+// earthRadius is the mean radius of the Earth in kilometers.
+const earthRadius = 6371.0088
+
+// accurateWithin is the distance in kilometers from the ruler latitude within which measurements are precise.
+const accurateWithin = 500
+
+// maxMiterTurn is the sharpest turn in degrees for which OffsetLine uses a mitered corner.
+const maxMiterTurn = 150
+
 // Point is a [longitude, latitude] array
 type Point [2]float64
 
+// Point3 is a [longitude, latitude, elevation] array, where the elevation is in ruler units
+type Point3 [3]float64
+
 // Bbox is a [southwestLon, southwestLat, northeastLon, northeastLat] array
 type Bbox [4]float64
 
@@ -50,7 +74,23 @@ type PointOnLine struct {
 	t     float64
 }
 
+// Coordinate returns the closest point on the line.
+func (pol PointOnLine) Coordinate() Point {
+	return pol.point
+}
+
+// Index returns the start index of the segment with the closest point.
+func (pol PointOnLine) Index() int {
+	return pol.index
+}
+
+// T returns a parameter from 0 to 1 that indicates where the closest point is on the segment.
+func (pol PointOnLine) T() float64 {
+	return pol.t
+}
+
 // Units provides convenience conversions from kilometers to different distance units.
+// It should not be modified directly: use RegisterUnit to add units safely.
 var Units = map[string]float64{
 	"kilometers":    1,
 	"miles":         1000 / 1609.344,
@@ -62,19 +102,96 @@ var Units = map[string]float64{
 	"inches":        1000 / 0.0254,
 }
 
+// unitsMutex guards concurrent access to Units.
+var unitsMutex sync.RWMutex
+
+// RegisterUnit adds a unit, or overrides an existing one, given the number of units in a kilometer.
+// An error will be returned if the name is empty or if the scale is not a positive finite number.
+func RegisterUnit(name string, kmScale float64) error {
+	if name == "" {
+		return errors.New("unit name must not be empty")
+	}
+	if !(kmScale > 0) || math.IsInf(kmScale, 1) {
+		return errors.New("unit scale must be a positive finite number")
+	}
+
+	unitsMutex.Lock()
+	defer unitsMutex.Unlock()
+	Units[name] = kmScale
+	return nil
+}
+
+// ConvertUnits converts a distance from a unit to another.
+// An error will be returned if one of the units is not in Units.
+func ConvertUnits(value float64, from string, to string) (float64, error) {
+	fromScale, ok := unitScale(from)
+	if !ok {
+		return 0, errors.New(from + " is not a valid unit")
+	}
+	toScale, ok := unitScale(to)
+	if !ok {
+		return 0, errors.New(to + " is not a valid unit")
+	}
+	return value * toScale / fromScale, nil
+}
+
+// unitScale returns the number of the given units in a kilometer, and whether the unit is registered.
+func unitScale(unit string) (float64, bool) {
+	unitsMutex.RLock()
+	defer unitsMutex.RUnlock()
+	scale, ok := Units[unit]
+	return scale, ok
+}
+
 // NewRuler instantiates a new ruler from a latitude and a unit.
 // An error will be returned if the unit provided is not in Units, and the default "kilometers" will be used.
 func NewRuler(lat float64, unit string) (Ruler, error) {
-	var m float64
-	var e error
-	if scale, ok := Units[unit]; ok {
-		m = scale
-	} else {
-		// falling back to the default kilometers
-		m = 1
-		e = errors.New(unit + " is not a valid unit")
+	m, unit, e := rulerUnit(unit)
+	kx, ky := multipliers(lat)
+
+	return Ruler{kx: m * kx, ky: m * ky, lat: lat, unit: unit, kmScale: m}, e
+}
+
+// rulerConfig is the JSON representation of a ruler.
+type rulerConfig struct {
+	Lat  float64 `json:"lat"`
+	Unit string  `json:"unit"`
+}
+
+// MarshalJSON encodes the latitude and unit of the ruler.
+func (r Ruler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rulerConfig{Lat: r.lat, Unit: r.unit})
+}
+
+// UnmarshalJSON decodes a latitude and unit, and instantiates the ruler with NewRuler.
+// An error will be returned if the unit is not in Units.
+func (r *Ruler) UnmarshalJSON(data []byte) error {
+	var config rulerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+
+	ruler, err := NewRuler(config.Lat, config.Unit)
+	if err != nil {
+		return err
 	}
+	*r = ruler
+	return nil
+}
+
+// rulerUnit returns the number of the given units in a kilometer along with the unit name.
+// If the unit is not in Units, the default kilometers are returned with an error.
+func rulerUnit(unit string) (float64, string, error) {
+	if scale, ok := unitScale(unit); ok {
+		return scale, unit, nil
+	}
+	// falling back to the default kilometers
+	return 1, "kilometers", errors.New(unit + " is not a valid unit")
+}
 
+// multipliers returns the multipliers for converting longitude and latitude degrees
+// into kilometers at the given latitude.
+func multipliers(lat float64) (float64, float64) {
 	cos := math.Cos(lat * math.Pi / 180)
 	cos2 := 2*cos*cos - 1
 	cos3 := 2*cos*cos2 - cos
@@ -82,22 +199,225 @@ func NewRuler(lat float64, unit string) (Ruler, error) {
 	cos5 := 2*cos*cos4 - cos3
 
 	// multipliers for converting longitude and latitude degrees into distance (http://1.usa.gov/1Wb1bv7)
-	kx := m * (111.41513*cos - 0.09455*cos3 + 0.00012*cos5)
-	ky := m * (111.13209 - 0.56605*cos2 + 0.0012*cos4)
+	kx := 111.41513*cos - 0.09455*cos3 + 0.00012*cos5
+	ky := 111.13209 - 0.56605*cos2 + 0.0012*cos4
+
+	return kx, ky
+}
+
+// WithUnit returns a ruler for the same latitude in a different unit, rescaling the ruler multipliers.
+// Like NewRuler, an error will be returned if the unit provided is not in Units, and the default "kilometers" will be used.
+func (r Ruler) WithUnit(unit string) (Ruler, error) {
+	m, unit, e := rulerUnit(unit)
+	ratio := m / r.kmScale
+	return Ruler{kx: r.kx * ratio, ky: r.ky * ratio, lat: r.lat, unit: unit, kmScale: m}, e
+}
+
+// NewRulerFromTile instantiates a new ruler from the y and z coordinates of a slippy map tile,
+// using the latitude of the tile center.
+// An error will be returned if z is negative or if y is not a valid tile row at zoom level z.
+func NewRulerFromTile(y int, z int, unit string) (Ruler, error) {
+	if z < 0 {
+		return Ruler{}, errors.New("zoom level must be non-negative")
+	}
+	if y < 0 || (z < 63 && y >= 1<<uint(z)) {
+		return Ruler{}, errors.New("tile y coordinate is out of range for the zoom level")
+	}
+
+	n := math.Pi * (1 - 2*(float64(y)+0.5)/math.Exp2(float64(z)))
+	lat := math.Atan(math.Sinh(n)) * 180 / math.Pi
+
+	return NewRuler(lat, unit)
+}
+
+// NewRulerFromPoint instantiates a new ruler from the latitude of a point and a unit.
+// Errors are returned the same way as with NewRuler.
+func NewRulerFromPoint(p Point, unit string) (Ruler, error) {
+	return NewRuler(p[1], unit)
+}
+
+// Kx returns the multiplier for converting longitude degrees into ruler units.
+func (r Ruler) Kx() float64 {
+	return r.kx
+}
+
+// Ky returns the multiplier for converting latitude degrees into ruler units.
+func (r Ruler) Ky() float64 {
+	return r.ky
+}
+
+// Lat returns the latitude the ruler was built for.
+func (r Ruler) Lat() float64 {
+	return r.lat
+}
+
+// Unit returns the unit of the ruler. It is "kilometers" if the ruler was built with an invalid unit.
+func (r Ruler) Unit() string {
+	return r.unit
+}
+
+// AccurateWithin returns the distance in ruler units from the latitude the ruler was built for,
+// within which its measurements are very precise (within a 0.1% margin of error).
+func (r Ruler) AccurateWithin() float64 {
+	return accurateWithin * r.scale()
+}
+
+// CheckPoint returns a boolean value, whether the given point is close enough in latitude
+// to the latitude the ruler was built for to trust measurements involving it.
+func (r Ruler) CheckPoint(p Point) bool {
+	return math.Abs(p[1]-r.lat)*r.ky <= r.AccurateWithin()
+}
+
+// scale returns the number of ruler units in a kilometer.
+func (r Ruler) scale() float64 {
+	return r.kmScale
+}
+
+// Project returns the coordinates in ruler units of a point in the flat projection used by the ruler.
+func (r Ruler) Project(p Point) (float64, float64) {
+	return p[0] * r.kx, p[1] * r.ky
+}
 
-	return Ruler{kx: kx, ky: ky}, e
+// Unproject returns the point at the given coordinates in ruler units in the flat projection used by the ruler.
+func (r Ruler) Unproject(x float64, y float64) Point {
+	return Point{x / r.kx, y / r.ky}
 }
 
 // Distance gives the distance in ruler units between two points.
+// Points more than 180 degrees of longitude apart are assumed to be on each side of the antimeridian.
+// Only Distance, LineDistance, Distances, Bearing, Along and PointOnLine (with the methods built on them)
+// make that assumption: other methods, like PointInPolygon, Area or ClipToBbox, use raw longitudes.
 func (r Ruler) Distance(a Point, b Point) float64 {
-	dx := (a[0] - b[0]) * r.kx
+	return math.Sqrt(r.SquaredDistance(a, b))
+}
+
+// SquaredDistance gives the squared distance in ruler units between two points.
+// It is faster than Distance and useful when only comparing distances.
+// Points more than 180 degrees of longitude apart are assumed to be on each side of the antimeridian.
+func (r Ruler) SquaredDistance(a Point, b Point) float64 {
+	dx := wrapLongitude(a[0]-b[0]) * r.kx
 	dy := (a[1] - b[1]) * r.ky
-	return math.Sqrt(dx*dx + dy*dy)
+	return dx*dx + dy*dy
+}
+
+// Distances gives the distances in ruler units between each pair of points a[i], b[i], like Distance.
+// An error will be returned if the two slices have different lengths.
+func (r Ruler) Distances(a []Point, b []Point) ([]float64, error) {
+	if len(a) != len(b) {
+		return nil, errors.New("point slices must have the same length")
+	}
+
+	distances := make([]float64, len(a))
+	for i := range a {
+		dx := wrapLongitude(a[i][0]-b[i][0]) * r.kx
+		dy := (a[i][1] - b[i][1]) * r.ky
+		distances[i] = math.Sqrt(dx*dx + dy*dy)
+	}
+	return distances, nil
+}
+
+// DistanceMatrix returns the distances in ruler units between every pair of the given points,
+// as a symmetric matrix where the element [i][j] is the distance between pts[i] and pts[j].
+func (r Ruler) DistanceMatrix(pts []Point) [][]float64 {
+	matrix := make([][]float64, len(pts))
+	for i := range matrix {
+		matrix[i] = make([]float64, len(pts))
+	}
+
+	for i := range pts {
+		for j := i + 1; j < len(pts); j++ {
+			d := r.Distance(pts[i], pts[j])
+			matrix[i][j] = d
+			matrix[j][i] = d
+		}
+	}
+	return matrix
+}
+
+// HaversineDistance gives the great-circle distance in ruler units between two points, on a spherical Earth.
+// It is slower than Distance but remains accurate over long distances.
+func (r Ruler) HaversineDistance(a Point, b Point) float64 {
+	return earthRadius * r.scale() * centralAngle(a, b)
+}
+
+// AngularDistance gives the great-circle angular separation in degrees of arc between two points,
+// which does not depend on the unit of the ruler.
+func (r Ruler) AngularDistance(a Point, b Point) float64 {
+	return centralAngle(a, b) * 180 / math.Pi
+}
+
+// Distance3 gives the distance in ruler units between two points with elevation,
+// combining the horizontal distance with the elevation difference.
+func (r Ruler) Distance3(a Point3, b Point3) float64 {
+	dz := b[2] - a[2]
+	return math.Sqrt(r.SquaredDistance(Point{a[0], a[1]}, Point{b[0], b[1]}) + dz*dz)
+}
+
+// Segments returns the pairs of consecutive points forming each segment of the line.
+// Lines with fewer than two points return an empty slice.
+func (r Ruler) Segments(l Line) [][2]Point {
+	if len(l) < 2 {
+		return [][2]Point{}
+	}
+
+	segments := make([][2]Point, len(l)-1)
+	for i := range segments {
+		segments[i] = [2]Point{l[i], l[i+1]}
+	}
+	return segments
+}
+
+// CumulativeDistances returns the distance in ruler units from the start of the line to each of its points.
+func (r Ruler) CumulativeDistances(l Line) []float64 {
+	distances := make([]float64, len(l))
+	for i := 1; i < len(l); i++ {
+		distances[i] = distances[i-1] + r.Distance(l[i-1], l[i])
+	}
+	return distances
+}
+
+// LineDistanceStream returns the total distance, in ruler units, of a linestring whose points are
+// received from the given channel, once the channel is closed. Only the previous point is kept in memory.
+func (r Ruler) LineDistanceStream(pts <-chan Point) float64 {
+	var distance float64
+	var prev Point
+	first := true
+
+	for p := range pts {
+		if !first {
+			distance += r.Distance(prev, p)
+		}
+		prev = p
+		first = false
+	}
+	return distance
+}
+
+// LineDistance3 returns the total distance of a linestring of points with elevation, in ruler units.
+func (r Ruler) LineDistance3(l []Point3) float64 {
+	var distance float64
+
+	for i := 0; i < len(l)-1; i++ {
+		distance += r.Distance3(l[i], l[i+1])
+	}
+	return distance
+}
+
+// Slope returns the grade between two points with elevation, as a percentage of the elevation
+// difference over the horizontal distance. It is negative downhill, and 0 if the points are
+// at the same horizontal position.
+func (r Ruler) Slope(a Point3, b Point3) float64 {
+	run := r.Distance(Point{a[0], a[1]}, Point{b[0], b[1]})
+	if run == 0 {
+		return 0
+	}
+	return (b[2] - a[2]) / run * 100
 }
 
 // Bearing gives the bearing in degrees from north between two points.
+// Like Distance, points more than 180 degrees of longitude apart are assumed to be on each side of the antimeridian.
 func (r Ruler) Bearing(a Point, b Point) float64 {
-	dx := (b[0] - a[0]) * r.kx
+	dx := wrapLongitude(b[0]-a[0]) * r.kx
 	dy := (b[1] - a[1]) * r.ky
 	if dx == 0 && dy == 0 {
 		return 0
@@ -110,12 +430,75 @@ func (r Ruler) Bearing(a Point, b Point) float64 {
 	return bearing
 }
 
+// BearingAtPointOnLine gives the bearing in degrees from north of the segment of the line on which
+// the given PointOnLine landed. An invalid index or a line with a single point returns 0.
+func (r Ruler) BearingAtPointOnLine(l Line, pol PointOnLine) float64 {
+	if pol.index < 0 || pol.index >= len(l)-1 {
+		return 0
+	}
+	return r.Bearing(l[pol.index], l[pol.index+1])
+}
+
+// Bearings returns the bearings in degrees from north of each segment of the line.
+func (r Ruler) Bearings(l Line) []float64 {
+	if len(l) < 2 {
+		return []float64{}
+	}
+
+	bearings := make([]float64, len(l)-1)
+	for i := range bearings {
+		bearings[i] = r.Bearing(l[i], l[i+1])
+	}
+	return bearings
+}
+
+// CompassBearing gives the bearing in degrees from north between two points, in the [0, 360) range.
+func (r Ruler) CompassBearing(a Point, b Point) float64 {
+	bearing := r.Bearing(a, b)
+	if bearing < 0 {
+		bearing += 360
+	}
+	return bearing
+}
+
+// RhumbDistance gives the distance in ruler units between two points along a rhumb line (a line of constant bearing).
+// Since the ruler works in a locally flat projection, this is the same as the straight distance.
+func (r Ruler) RhumbDistance(a Point, b Point) float64 {
+	return r.Distance(a, b)
+}
+
+// RhumbBearing gives the constant bearing in degrees from north to follow to go from a to b along a rhumb line.
+// Following that bearing for RhumbDistance from a with Destination lands on b.
+func (r Ruler) RhumbBearing(a Point, b Point) float64 {
+	return r.Bearing(a, b)
+}
+
 // Offset returns a point located dx, dy ruler units from the given point.
 func (r Ruler) Offset(p Point, dx float64, dy float64) Point {
 	return Point{p[0] + dx/r.kx, p[1] + dy/r.ky}
 }
 
+// Grid returns a lattice of rows by cols points centered on the given point and spaced by spacing ruler units.
+// Rows go from south to north, and the points of each row go from west to east.
+func (r Ruler) Grid(center Point, cols int, rows int, spacing float64) [][]Point {
+	if cols <= 0 || rows <= 0 {
+		return [][]Point{}
+	}
+
+	grid := make([][]Point, rows)
+	for i := range grid {
+		grid[i] = make([]Point, cols)
+		dy := (float64(i) - float64(rows-1)/2) * spacing
+		for j := range grid[i] {
+			dx := (float64(j) - float64(cols-1)/2) * spacing
+			grid[i][j] = r.Offset(center, dx, dy)
+		}
+	}
+	return grid
+}
+
 // LineDistance returns the total distance of a linestring, in ruler units.
+// Like Distance, consecutive points more than 180 degrees of longitude apart are assumed to cross the antimeridian.
 func (r Ruler) LineDistance(l Line) float64 {
 	var distance float64
 
@@ -125,158 +508,1448 @@ func (r Ruler) LineDistance(l Line) float64 {
 	return distance
 }
 
+// Perimeter returns the total length of all the rings of a polygon, in ruler units.
+// Rings that are not closed are closed implicitly.
+func (r Ruler) Perimeter(p Polygon) float64 {
+	var perimeter float64
+
+	for _, ring := range p {
+		perimeter += r.LineDistance(ring)
+		if len(ring) > 1 && ring[0] != ring[len(ring)-1] {
+			perimeter += r.Distance(ring[len(ring)-1], ring[0])
+		}
+	}
+	return perimeter
+}
+
+// OuterRingLength returns the length of the outer ring of a polygon, in ruler units, ignoring its holes.
+// Like Perimeter, a ring that is not closed is closed implicitly. An empty polygon returns 0.
+func (r Ruler) OuterRingLength(p Polygon) float64 {
+	if len(p) == 0 {
+		return 0
+	}
+	return r.Perimeter(p[:1])
+}
+
 // Destination returns a new point given distance and bearing from the starting point.
 func (r Ruler) Destination(p Point, d float64, b float64) Point {
 	var a = b * math.Pi / 180
 	return r.Offset(p, math.Sin(a)*d, math.Cos(a)*d)
 }
 
+// DestinationAndBack returns a new point given distance and bearing from the starting point,
+// along with the bearing from that new point back to the starting point.
+func (r Ruler) DestinationAndBack(p Point, d float64, b float64) (Point, float64) {
+	destination := r.Destination(p, d, b)
+	return destination, r.Bearing(destination, p)
+}
+
+// HaversineDestination returns a new point given distance and bearing from the starting point, following
+// a great circle on a spherical Earth. It is slower than Destination but remains accurate over long distances.
+func (r Ruler) HaversineDestination(p Point, d float64, b float64) Point {
+	delta := d / r.scale() / earthRadius
+	theta := b * math.Pi / 180
+	lat1 := p[1] * math.Pi / 180
+	lon1 := p[0] * math.Pi / 180
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+
+	return Point{normalizeAngle(lon2 * 180 / math.Pi), lat2 * 180 / math.Pi}
+}
+
+// Midpoint returns the point halfway between two points.
+func (r Ruler) Midpoint(a Point, b Point) Point {
+	return interpolate(a, b, 0.5)
+}
+
 // Area returns the total area, in squared ruler units, of a polygon.
 func (r Ruler) Area(p Polygon) float64 {
 	var sum float64
 
 	for i := 0; i < len(p); i++ {
-		var ring Line = p[i]
-		for j, len, k := 0, len(ring), len(ring)-1; j < len; k, j = j+1, j+1 {
-			var isNotHole float64 = 1
-			if i > 0 {
-				isNotHole = -1
-			}
-			sum += (ring[j][0] - ring[k][0]) * (ring[j][1] + ring[k][1]) * isNotHole
+		var isNotHole float64 = 1
+		if i > 0 {
+			isNotHole = -1
 		}
+		sum += ringSum(p[i]) * isNotHole
 	}
 
 	return (math.Abs(sum) / 2) * r.kx * r.ky
 }
 
-// Along returns the point located at the given distance along the given line, in ruler units.
-func (r Ruler) Along(l Line, dist float64) Point {
-	var sum float64
-
-	if dist <= 0 {
-		return l[0]
+// AreaErr returns the total area, in squared ruler units, of a polygon like Area.
+// An error will be returned instead if the polygon has no outer ring or if a ring has fewer than three points.
+func (r Ruler) AreaErr(p Polygon) (float64, error) {
+	if len(p) == 0 {
+		return 0, errors.New("polygon has no outer ring")
 	}
-
-	for i := 0; i < len(l)-1; i++ {
-		p0 := l[i]
-		p1 := l[i+1]
-		d := r.Distance(p0, p1)
-		sum += d
-		if sum > dist {
-			return interpolate(p0, p1, (dist-(sum-d))/d)
+	for i, ring := range p {
+		if len(ring) < 3 {
+			return 0, errors.New("ring " + strconv.Itoa(i) + " has fewer than three points")
 		}
 	}
+	return r.Area(p), nil
+}
 
-	return l[len(l)-1]
+// TriangleArea returns the area, in squared ruler units, of the triangle formed by three points.
+// It is faster than building a Polygon for Area.
+func (r Ruler) TriangleArea(a Point, b Point, c Point) float64 {
+	cross := (b[0]-a[0])*(c[1]-a[1]) - (c[0]-a[0])*(b[1]-a[1])
+	return math.Abs(cross) / 2 * r.kx * r.ky
 }
 
-// PointOnLine snaps the given point on the line. The returned PointOnLine object
-// gives the point coordinates, the index of the segment in the line where the point landed,
-// and a proportion value that indicates where on that segment the point is located.
-func (r Ruler) PointOnLine(l Line, p Point) PointOnLine {
-	var minDist float64 = math.Inf(1)
-	var minX, minY, minT, x, y, dx, dy, t float64
-	var minI int
+// RingArea returns the area, in squared ruler units, of a line treated as a closed ring.
+func (r Ruler) RingArea(ring Line) float64 {
+	return math.Abs(r.SignedRingArea(ring))
+}
 
-	for i := 0; i < len(l)-1; i++ {
+// SignedRingArea returns the area, in squared ruler units, of a line treated as a closed ring,
+// positive if the ring is counter-clockwise and negative if it is clockwise.
+func (r Ruler) SignedRingArea(ring Line) float64 {
+	return -ringSum(ring) / 2 * r.kx * r.ky
+}
 
-		x = l[i][0]
-		y = l[i][1]
-		dx = (l[i+1][0] - x) * r.kx
-		dy = (l[i+1][1] - y) * r.ky
+// SignedArea returns the sum of the signed areas of the rings of a polygon, in squared ruler units.
+// With a counter-clockwise outer ring and clockwise holes, as in GeoJSON, it is the positive area
+// of the polygon; a negative or unexpectedly small value reveals a wrong winding or a self-intersecting ring.
+func (r Ruler) SignedArea(p Polygon) float64 {
+	var sum float64
+	for _, ring := range p {
+		sum += r.SignedRingArea(ring)
+	}
+	return sum
+}
 
-		if dx != 0 || dy != 0 {
+// IntersectionArea returns the approximate area, in squared ruler units, shared by the outer rings of two polygons.
+// The outer ring of a is clipped against the outer ring of b with the Sutherland-Hodgman algorithm,
+// which requires b to be convex: the result is not reliable for a concave b. Holes are ignored.
+func (r Ruler) IntersectionArea(a Polygon, b Polygon) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
 
-			t = ((p[0]-x)*r.kx*dx + (p[1]-y)*r.ky*dy) / (dx*dx + dy*dy)
+	subject := openRing(a[0])
+	clip := openRing(b[0])
+	if len(subject) < 3 || len(clip) < 3 {
+		return 0
+	}
 
-			if t > 1 {
-				x = l[i+1][0]
-				y = l[i+1][1]
+	// the inside of each clip edge is on its left for a counter-clockwise ring, and on its right otherwise
+	sign := 1.0
+	if ringSum(clip) > 0 {
+		sign = -1
+	}
 
-			} else if t > 0 {
-				x += (dx / r.kx) * t
-				y += (dy / r.ky) * t
-			}
+	for i := range clip {
+		c1 := clip[i]
+		c2 := clip[(i+1)%len(clip)]
+		side := func(p Point) float64 {
+			return sign * ((c2[0]-c1[0])*(p[1]-c1[1]) - (c2[1]-c1[1])*(p[0]-c1[0]))
 		}
 
-		dx = (p[0] - x) * r.kx
-		dy = (p[1] - y) * r.ky
+		input := subject
+		subject = Line{}
+		for j, cur := range input {
+			prev := input[(j+len(input)-1)%len(input)]
+			dPrev := side(prev)
+			dCur := side(cur)
+			if dCur >= 0 {
+				if dPrev < 0 {
+					subject = append(subject, interpolate(prev, cur, dPrev/(dPrev-dCur)))
+				}
+				subject = append(subject, cur)
+			} else if dPrev >= 0 {
+				subject = append(subject, interpolate(prev, cur, dPrev/(dPrev-dCur)))
+			}
+		}
 
-		var sqDist = dx*dx + dy*dy
-		if sqDist < minDist {
-			minDist = sqDist
-			minX = x
-			minY = y
-			minI = i
-			minT = t
+		if len(subject) == 0 {
+			return 0
 		}
 	}
 
-	return PointOnLine{
-		point: Point{minX, minY},
-		index: minI,
-		t:     math.Max(0, math.Min(1, minT)),
-	}
+	return r.RingArea(subject)
 }
 
-// LineSlice returns the portion of the given line that lies between provided start
-// and end points (the points being snapped on the line).
-func (r Ruler) LineSlice(start Point, end Point, l Line) Line {
-	p1 := r.PointOnLine(l, start)
-	p2 := r.PointOnLine(l, end)
+// IsClockwise returns a boolean value, whether the given ring is in clockwise order.
+func (r Ruler) IsClockwise(ring Line) bool {
+	return r.SignedRingArea(ring) < 0
+}
 
-	if p1.index > p2.index || (p1.index == p2.index && p1.t < p2.t) {
-		p1, p2 = p2, p1
+// IsClosed returns a boolean value, whether the given ring has the same first and last point.
+// An empty ring is not closed.
+func (r Ruler) IsClosed(ring Line) bool {
+	return len(ring) > 0 && ring[0] == ring[len(ring)-1]
+}
+
+// EnsureWinding returns a copy of the given ring, reversed if needed to be in clockwise
+// or counter-clockwise order.
+func (r Ruler) EnsureWinding(ring Line, clockwise bool) Line {
+	if r.IsClockwise(ring) != clockwise {
+		return r.Reverse(ring)
 	}
+	return r.CloneLine(ring)
+}
 
-	var slice Line = []Point{p1.point}
+// Centroid returns the area-weighted centroid of a polygon, holes being subtracted from the outer ring.
+// If the polygon has no area, the arithmetic mean of the outer ring vertices is returned instead.
+func (r Ruler) Centroid(p Polygon) Point {
+	if len(p) == 0 || len(p[0]) == 0 {
+		return Point{}
+	}
 
-	left := p1.index + 1
-	right := p2.index
+	origin := p[0][0]
+	var area, cx, cy float64
 
-	if l[left] != slice[0] && left <= right {
-		slice = append(slice, l[left])
-	}
+	for i := 0; i < len(p); i++ {
+		var ring Line = p[i]
+		var ringArea, ringX, ringY float64
+		for j, len, k := 0, len(ring), len(ring)-1; j < len; k, j = j, j+1 {
+			x0 := (ring[k][0] - origin[0]) * r.kx
+			y0 := (ring[k][1] - origin[1]) * r.ky
+			x1 := (ring[j][0] - origin[0]) * r.kx
+			y1 := (ring[j][1] - origin[1]) * r.ky
+			cross := x0*y1 - x1*y0
+			ringArea += cross
+			ringX += (x0 + x1) * cross
+			ringY += (y0 + y1) * cross
+		}
 
-	for i := left + 1; i <= right; i++ {
-		slice = append(slice, l[i])
+		// rings are weighted positively for the outer ring and negatively for holes, whatever their winding
+		sign := 1.0
+		if (ringArea < 0) != (i > 0) {
+			sign = -1
+		}
+		area += ringArea * sign
+		cx += ringX * sign
+		cy += ringY * sign
 	}
 
-	if l[right] != p2.point {
-		slice = append(slice, p2.point)
+	if area == 0 {
+		var ring Line = p[0]
+		n := len(ring)
+		if n > 1 && ring[0] == ring[n-1] {
+			n--
+		}
+		var sumX, sumY float64
+		for i := 0; i < n; i++ {
+			sumX += ring[i][0]
+			sumY += ring[i][1]
+		}
+		return Point{sumX / float64(n), sumY / float64(n)}
 	}
 
-	return slice
+	return Point{
+		origin[0] + cx/(3*area)/r.kx,
+		origin[1] + cy/(3*area)/r.ky,
+	}
 }
 
-// LineSliceAlong returns the portion of the given line that lies between provided start
-// and end distances, in ruler units.
-func (r Ruler) LineSliceAlong(start float64, stop float64, l Line) Line {
-	var sum float64
-	var slice []Point
+// MeanCenter returns the average of the given points, computed in the flat projection used by the ruler.
+// No points return a zero Point.
+func (r Ruler) MeanCenter(pts []Point) Point {
+	if len(pts) == 0 {
+		return Point{}
+	}
 
-	for i := 0; i < len(l)-1; i++ {
-		p0 := l[i]
-		p1 := l[i+1]
-		d := r.Distance(p0, p1)
+	var sumX, sumY float64
+	for _, p := range pts {
+		x, y := r.Project(p)
+		sumX += x
+		sumY += y
+	}
+	return r.Unproject(sumX/float64(len(pts)), sumY/float64(len(pts)))
+}
 
-		sum += d
+// MedianCenter returns the geometric median of the given points, which minimizes the sum of the distances
+// to the points, with the given number of iterations of Weiszfeld's algorithm starting from the mean center.
+// No points return a zero Point.
+func (r Ruler) MedianCenter(pts []Point, iterations int) Point {
+	center := r.MeanCenter(pts)
 
-		if sum > start && len(slice) == 0 {
-			slice = append(slice, interpolate(p0, p1, (start-(sum-d))/d))
+	for i := 0; i < iterations; i++ {
+		var sumX, sumY, sumWeights float64
+		for _, p := range pts {
+			d := r.Distance(center, p)
+			// the iteration is undefined on a point itself, which is skipped
+			if d == 0 {
+				continue
+			}
+			sumX += p[0] / d
+			sumY += p[1] / d
+			sumWeights += 1 / d
+		}
+		if sumWeights == 0 {
+			break
 		}
 
-		if sum >= stop {
-			slice = append(slice, interpolate(p0, p1, (stop-(sum-d))/d))
+		next := Point{sumX / sumWeights, sumY / sumWeights}
+		if next == center {
+			break
+		}
+		center = next
+	}
+
+	return center
+}
+
+// PoleOfInaccessibility returns the point inside the polygon that is the farthest from its boundary,
+// which is a good place for a label, using the polylabel algorithm. The precision is in ruler units,
+// and a non-positive precision is treated as a millionth of the polygon size.
+// An empty polygon returns a zero Point.
+func (r Ruler) PoleOfInaccessibility(p Polygon, precision float64) Point {
+	if len(p) == 0 || len(p[0]) == 0 {
+		return Point{}
+	}
+
+	b := r.LineBbox(p[0])
+	width := (b[2] - b[0]) * r.kx
+	height := (b[3] - b[1]) * r.ky
+	cellSize := math.Min(width, height)
+	if cellSize == 0 {
+		return Point{b[0], b[1]}
+	}
+	if !(precision > 0) {
+		precision = math.Max(width, height) * 1e-6
+	}
+
+	queue := &poleQueue{}
+	for x := 0.0; x < width; x += cellSize {
+		for y := 0.0; y < height; y += cellSize {
+			center := r.Offset(Point{b[0], b[1]}, x+cellSize/2, y+cellSize/2)
+			heap.Push(queue, r.poleCell(center, cellSize/2, p))
+		}
+	}
+
+	best := r.poleCell(r.Centroid(p), 0, p)
+	if center := r.poleCell(r.BboxCenter(b), 0, p); center.dist > best.dist {
+		best = center
+	}
+
+	for queue.Len() > 0 {
+		cell := heap.Pop(queue).(poleCell)
+		if cell.dist > best.dist {
+			best = cell
+		}
+		if cell.max-best.dist <= precision {
+			continue
+		}
+
+		h := cell.half / 2
+		for _, d := range [][2]float64{{-h, -h}, {h, -h}, {-h, h}, {h, h}} {
+			heap.Push(queue, r.poleCell(r.Offset(cell.center, d[0], d[1]), h, p))
+		}
+	}
+
+	return best.center
+}
+
+// poleCell returns a square cell of the given half size in ruler units, with the signed distance from
+// its center to the polygon boundary (negative outside) and the maximum distance possible within the cell.
+func (r Ruler) poleCell(center Point, half float64, p Polygon) poleCell {
+	dist := r.distanceToRings(center, p)
+	if !r.PointInPolygon(center, p) {
+		dist = -dist
+	}
+	return poleCell{center: center, half: half, dist: dist, max: dist + half*math.Sqrt2}
+}
+
+// poleCell is a cell of the grid used by PoleOfInaccessibility.
+type poleCell struct {
+	center Point
+	half   float64
+	dist   float64
+	max    float64
+}
+
+// poleQueue is a priority queue of cells, the cell with the highest potential distance first.
+type poleQueue []poleCell
+
+func (q poleQueue) Len() int            { return len(q) }
+func (q poleQueue) Less(i, j int) bool  { return q[i].max > q[j].max }
+func (q poleQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *poleQueue) Push(x interface{}) { *q = append(*q, x.(poleCell)) }
+func (q *poleQueue) Pop() interface{} {
+	old := *q
+	cell := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return cell
+}
+
+// PointInPolygon returns a boolean value, whether the given point is inside the given polygon
+// (inside the outer ring and outside of any hole). Points lying exactly on the boundary
+// of any ring, including its vertices, are considered inside.
+func (r Ruler) PointInPolygon(p Point, poly Polygon) bool {
+	inside := false
+
+	for i := 0; i < len(poly); i++ {
+		var ring Line = poly[i]
+		for j, len, k := 0, len(ring), len(ring)-1; j < len; k, j = j, j+1 {
+			a := ring[k]
+			b := ring[j]
+
+			if onSegment(p, a, b) {
+				return true
+			}
+
+			if (a[1] > p[1]) != (b[1] > p[1]) &&
+				p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+// Along returns the point located at the given distance along the given line, in ruler units.
+// An empty line returns a zero Point.
+func (r Ruler) Along(l Line, dist float64) Point {
+	var sum float64
+
+	if len(l) == 0 {
+		return Point{}
+	}
+
+	if dist <= 0 {
+		return l[0]
+	}
+
+	for i := 0; i < len(l)-1; i++ {
+		p0 := l[i]
+		p1 := l[i+1]
+		d := r.Distance(p0, p1)
+		sum += d
+		if sum > dist {
+			return interpolateAcross(p0, p1, (dist-(sum-d))/d)
+		}
+	}
+
+	return l[len(l)-1]
+}
+
+// AdvanceAlong snaps the given point on the line and returns the point located at the given distance
+// in ruler units further along the line, or back toward its start if the distance is negative.
+// The result is clamped to the ends of the line, and an empty line returns a zero Point.
+func (r Ruler) AdvanceAlong(l Line, from Point, dist float64) Point {
+	return r.Along(l, r.LineDistance(l)-r.RemainingDistance(l, from)+dist)
+}
+
+// AlongFraction returns the point located at the given fraction of the total length of the line.
+// The fraction is clamped to the [0, 1] range.
+func (r Ruler) AlongFraction(l Line, frac float64) Point {
+	frac = math.Max(0, math.Min(1, frac))
+	return r.Along(l, frac*r.LineDistance(l))
+}
+
+// SampleAlong returns the points located every interval ruler units along the line, starting with
+// its first point and ending with its last point. An error will be returned if interval is not positive.
+func (r Ruler) SampleAlong(l Line, interval float64) ([]Point, error) {
+	if !(interval > 0) {
+		return nil, errors.New("interval must be positive")
+	}
+
+	if len(l) < 2 {
+		return append([]Point{}, l...), nil
+	}
+
+	var dists []float64
+	total := r.LineDistance(l)
+	for k := 0; float64(k)*interval <= total; k++ {
+		dists = append(dists, float64(k)*interval)
+	}
+
+	samples := r.alongAll(l, dists)
+	if last := l[len(l)-1]; samples[len(samples)-1] != last {
+		samples = append(samples, last)
+	}
+
+	return samples, nil
+}
+
+// ResampleN returns n points equally spaced along the line, the first and last ones
+// being the first and last points of the line. An error will be returned if n is lower
+// than 2 or if the line is empty.
+func (r Ruler) ResampleN(l Line, n int) (Line, error) {
+	if n < 2 {
+		return nil, errors.New("number of points must be at least 2")
+	}
+	if len(l) == 0 {
+		return nil, errors.New("line must not be empty")
+	}
+	if len(l) == 1 {
+		resampled := make(Line, n)
+		for i := range resampled {
+			resampled[i] = l[0]
+		}
+		return resampled, nil
+	}
+
+	dists := make([]float64, n-1)
+	total := r.LineDistance(l)
+	for k := range dists {
+		dists[k] = total * float64(k) / float64(n-1)
+	}
+
+	return append(r.alongAll(l, dists), l[len(l)-1]), nil
+}
+
+// alongAll returns the points located at each of the given increasing distances along the given line
+// of at least two points, in a single pass over the line.
+func (r Ruler) alongAll(l Line, dists []float64) Line {
+	points := make(Line, len(dists))
+	var sum float64
+	i := 0
+	d := r.Distance(l[0], l[1])
+
+	for k, dist := range dists {
+		for i < len(l)-2 && sum+d < dist {
+			sum += d
+			i++
+			d = r.Distance(l[i], l[i+1])
+		}
+
+		if d > 0 {
+			points[k] = interpolate(l[i], l[i+1], math.Max(0, math.Min(1, (dist-sum)/d)))
+		} else {
+			points[k] = l[i]
+		}
+	}
+	return points
+}
+
+// PointOnLine snaps the given point on the line. The returned PointOnLine object
+// gives the point coordinates, the index of the segment in the line where the point landed,
+// and a proportion value that indicates where on that segment the point is located.
+// A line with a single point snaps to that point, and an empty line returns an index of -1.
+func (r Ruler) PointOnLine(l Line, p Point) PointOnLine {
+	pol, _ := r.pointOnLine(l, p)
+	return pol
+}
+
+// SnapWithEndpointFlag snaps the given point on the line like PointOnLine, and also returns whether
+// the snapped point is the first or the last point of the line rather than somewhere along it.
+func (r Ruler) SnapWithEndpointFlag(l Line, p Point) (PointOnLine, bool) {
+	pol := r.PointOnLine(l, p)
+	if pol.index < 0 {
+		return pol, false
+	}
+	return pol, (pol.index == 0 && pol.t == 0) || (pol.index == len(l)-2 && pol.t == 1)
+}
+
+// LinearReference snaps the given point on the line and returns the distance along the line to the snapped point,
+// and the distance from the snapped point to the given point, positive on the left side of the line
+// and negative on its right side like SideOfLine, both in ruler units. Points beyond the ends of the line
+// are snapped on the extension of the first or last segment, so the overshoot is part of the distance along,
+// which is then negative or greater than the length of the line. Lines with fewer than two points return 0, 0.
+func (r Ruler) LinearReference(l Line, p Point) (float64, float64) {
+	if len(l) < 2 {
+		return 0, 0
+	}
+
+	pol := r.PointOnLine(l, p)
+	a, b := l[pol.index], l[pol.index+1]
+	foot, t := pol.point, pol.t
+	if ft, ftt := r.PerpendicularFoot(a, b, p); (pol.index == 0 && ftt < 0) || (pol.index == len(l)-2 && ftt > 1) {
+		foot, t = ft, ftt
+	}
+
+	along := r.LineDistance(l[:pol.index+1]) + t*r.Distance(a, b)
+	offset := r.Distance(foot, p) * float64(r.SideOfLine(a, b, p))
+	return along, offset
+}
+
+// FromLinearReference returns the point located at the given distance along the line and offset from it,
+// positive on the left side and negative on the right side, which is the inverse of LinearReference.
+// Distances before the start or past the end of the line extend its first or last segment.
+func (r Ruler) FromLinearReference(l Line, along float64, offset float64) Point {
+	if len(l) < 2 {
+		return r.Along(l, along)
+	}
+
+	if along < 0 {
+		bearing := r.Bearing(l[0], l[1])
+		return r.Destination(r.Destination(l[0], along, bearing), offset, bearing-90)
+	}
+
+	if length := r.LineDistance(l); along > length {
+		end := l[len(l)-1]
+		bearing := r.Bearing(l[len(l)-2], end)
+		return r.Destination(r.Destination(end, along-length, bearing), offset, bearing-90)
+	}
+
+	p, _ := r.LabelAnchor(l, along, -offset)
+	return p
+}
+
+// DistanceToLine returns the distance in ruler units from the given point to the closest point on the line.
+// An empty line returns an infinite distance.
+func (r Ruler) DistanceToLine(l Line, p Point) float64 {
+	_, sqDist := r.pointOnLine(l, p)
+	return math.Sqrt(sqDist)
+}
+
+// RemainingDistance snaps the given point on the line and returns the distance in ruler units
+// from the snapped point to the end of the line. Lines with fewer than two points return 0.
+func (r Ruler) RemainingDistance(l Line, p Point) float64 {
+	if len(l) < 2 {
+		return 0
+	}
+
+	pol := r.PointOnLine(l, p)
+	return r.Distance(pol.point, l[pol.index+1]) + r.LineDistance(l[pol.index+1:])
+}
+
+// DistanceToPolygon returns the distance in ruler units from the given point to the closest point
+// on the boundary of the polygon, or 0 if the point is inside the polygon.
+func (r Ruler) DistanceToPolygon(p Point, poly Polygon) float64 {
+	if r.PointInPolygon(p, poly) {
+		return 0
+	}
+	return r.distanceToRings(p, poly)
+}
+
+// distanceToRings returns the distance in ruler units from the given point to the closest point
+// on any ring of the polygon, whether the point is inside the polygon or not.
+func (r Ruler) distanceToRings(p Point, poly Polygon) float64 {
+	minDist := math.Inf(1)
+	for _, ring := range poly {
+		if len(ring) > 1 && ring[0] != ring[len(ring)-1] {
+			ring = append(r.CloneLine(ring), ring[0])
+		}
+		minDist = math.Min(minDist, r.DistanceToLine(ring, p))
+	}
+	return minDist
+}
+
+// PerpendicularFoot returns the foot of the perpendicular from the given point to the infinite line
+// through a and b, along with its position t relative to a and b, which is below 0 before a and above 1 after b.
+// If a and b are the same point, a is returned with a position of 0.
+func (r Ruler) PerpendicularFoot(a Point, b Point, p Point) (Point, float64) {
+	dx := wrapLongitude(b[0]-a[0]) * r.kx
+	dy := (b[1] - a[1]) * r.ky
+	sqLen := dx*dx + dy*dy
+	if sqLen == 0 {
+		return a, 0
+	}
+
+	t := (wrapLongitude(p[0]-a[0])*r.kx*dx + (p[1]-a[1])*r.ky*dy) / sqLen
+	return interpolateAcross(a, b, t), t
+}
+
+// SideOfLine returns on which side of the directed line from a to b the given point lies:
+// 1 on the left side, -1 on the right side, and 0 if the three points are collinear.
+func (r Ruler) SideOfLine(a Point, b Point, p Point) int {
+	cross := wrapLongitude(b[0]-a[0])*r.kx*(p[1]-a[1])*r.ky - (b[1]-a[1])*r.ky*wrapLongitude(p[0]-a[0])*r.kx
+	if cross > 0 {
+		return 1
+	} else if cross < 0 {
+		return -1
+	}
+	return 0
+}
+
+// pointOnLine snaps the given point on the line like PointOnLine, and also returns the squared distance
+// in ruler units from the given point to the snapped point.
+func (r Ruler) pointOnLine(l Line, p Point) (PointOnLine, float64) {
+	if len(l) == 0 {
+		return PointOnLine{index: -1}, math.Inf(1)
+	}
+
+	if len(l) == 1 {
+		return PointOnLine{point: l[0]}, r.SquaredDistance(l[0], p)
+	}
+
+	var minDist float64 = math.Inf(1)
+	var minX, minY, minT, x, y, dx, dy, t float64
+	var minI int
+
+	for i := 0; i < len(l)-1; i++ {
+
+		x = l[i][0]
+		y = l[i][1]
+		dx = wrapLongitude(l[i+1][0]-x) * r.kx
+		dy = (l[i+1][1] - y) * r.ky
+		t = 0
+
+		// segments too short for their squared length to be represented are treated as points
+		if sqLen := dx*dx + dy*dy; sqLen > 0 {
+
+			t = (wrapLongitude(p[0]-x)*r.kx*dx + (p[1]-y)*r.ky*dy) / sqLen
+
+			if t > 1 {
+				x = l[i+1][0]
+				y = l[i+1][1]
+
+			} else if t > 0 {
+				x = wrapCrossing(x+(dx/r.kx)*t, l[i+1][0]-x)
+				y += (dy / r.ky) * t
+			}
+		}
+
+		dx = wrapLongitude(p[0]-x) * r.kx
+		dy = (p[1] - y) * r.ky
+
+		var sqDist = dx*dx + dy*dy
+		if sqDist < minDist {
+			minDist = sqDist
+			minX = x
+			minY = y
+			minI = i
+			minT = t
+		}
+	}
+
+	return PointOnLine{
+		point: Point{minX, minY},
+		index: minI,
+		t:     math.Max(0, math.Min(1, minT)),
+	}, minDist
+}
+
+// NearestOnLines snaps the given point on the closest of the given lines, and returns
+// the index of that line along with the PointOnLine result. Ties are resolved with the lowest index,
+// empty lines are ignored, and an empty slice of lines returns an index of -1.
+func (r Ruler) NearestOnLines(lines []Line, p Point) (int, PointOnLine) {
+	minDist := math.Inf(1)
+	minI := -1
+	var minPol PointOnLine
+
+	for i, l := range lines {
+		if len(l) == 0 {
+			continue
+		}
+		pol := r.PointOnLine(l, p)
+		if d := r.SquaredDistance(pol.point, p); d < minDist {
+			minDist = d
+			minI = i
+			minPol = pol
+		}
+	}
+
+	return minI, minPol
+}
+
+// PointsOnLine snaps each of the given points on the line, in the same way as PointOnLine.
+// Points are processed concurrently by up to GOMAXPROCS workers, and the results are
+// returned in the same order as the given points.
+func (r Ruler) PointsOnLine(l Line, pts []Point) []PointOnLine {
+	results := make([]PointOnLine, len(pts))
+
+	if len(pts) == 0 {
+		return results
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(pts) {
+		workers = len(pts)
+	}
+
+	var wg sync.WaitGroup
+	chunk := (len(pts) + workers - 1) / workers
+	for start := 0; start < len(pts); start += chunk {
+		end := start + chunk
+		if end > len(pts) {
+			end = len(pts)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				results[i] = r.PointOnLine(l, pts[i])
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// NearestVertex returns the index of the vertex of the line closest to the given point,
+// and its distance in ruler units. Ties are resolved with the lowest index,
+// and an empty line returns an index of -1 with an infinite distance.
+func (r Ruler) NearestVertex(l Line, p Point) (int, float64) {
+	return r.NearestPoint(l, p)
+}
+
+// NearestPoint returns the index of the point closest to the query point, and its distance in ruler units.
+// Ties are resolved with the lowest index, and no points return an index of -1 with an infinite distance.
+func (r Ruler) NearestPoint(pts []Point, query Point) (int, float64) {
+	minDist := math.Inf(1)
+	minI := -1
+
+	for i, p := range pts {
+		d := r.SquaredDistance(p, query)
+		if d < minDist {
+			minDist = d
+			minI = i
+		}
+	}
+
+	return minI, math.Sqrt(minDist)
+}
+
+// ClosestPair returns the indexes of the two closest points among the given points, the lowest index first,
+// and their distance in ruler units. Points are swept from west to east so that only nearby pairs are compared,
+// which means that pairs crossing the antimeridian are not detected.
+// Ties are resolved with the lowest indexes, and fewer than two points return indexes of -1 with an infinite distance.
+func (r Ruler) ClosestPair(pts []Point) (int, int, float64) {
+	order := make([]int, len(pts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return pts[order[i]][0] < pts[order[j]][0] })
+
+	minDist := math.Inf(1)
+	minI, minJ := -1, -1
+	for a := range order {
+		for b := a + 1; b < len(order); b++ {
+			i, j := order[a], order[b]
+			dx := (pts[j][0] - pts[i][0]) * r.kx
+			if dx*dx > minDist {
+				break
+			}
+			if i > j {
+				i, j = j, i
+			}
+			d := r.SquaredDistance(pts[i], pts[j])
+			if d < minDist || (d == minDist && (i < minI || (i == minI && j < minJ))) {
+				minDist = d
+				minI, minJ = i, j
+			}
+		}
+	}
+
+	return minI, minJ, math.Sqrt(minDist)
+}
+
+// KNearest returns the indexes of the k points closest to the query point, sorted by increasing distance.
+// Ties are resolved with the lowest index, and all the indexes are returned if there are fewer than k points.
+func (r Ruler) KNearest(pts []Point, query Point, k int) []int {
+	if k <= 0 {
+		return []int{}
+	}
+
+	// a max-heap keeps the k closest points found so far, the farthest of them on top
+	candidates := &neighborHeap{}
+	for i, p := range pts {
+		n := neighbor{index: i, sqDist: r.SquaredDistance(p, query)}
+		if candidates.Len() < k {
+			heap.Push(candidates, n)
+		} else if (*candidates)[0].farther(n) {
+			(*candidates)[0] = n
+			heap.Fix(candidates, 0)
+		}
+	}
+
+	indexes := make([]int, candidates.Len())
+	for i := len(indexes) - 1; i >= 0; i-- {
+		indexes[i] = heap.Pop(candidates).(neighbor).index
+	}
+	return indexes
+}
+
+// neighbor is a point index with its squared distance to a query point, used by KNearest.
+type neighbor struct {
+	index  int
+	sqDist float64
+}
+
+// farther returns whether the neighbor is farther than the other one, ties being resolved with the highest index.
+func (n neighbor) farther(other neighbor) bool {
+	return n.sqDist > other.sqDist || (n.sqDist == other.sqDist && n.index > other.index)
+}
+
+// neighborHeap is a priority queue of neighbors, the farthest neighbor first.
+type neighborHeap []neighbor
+
+func (h neighborHeap) Len() int            { return len(h) }
+func (h neighborHeap) Less(i, j int) bool  { return h[i].farther(h[j]) }
+func (h neighborHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighborHeap) Push(x interface{}) { *h = append(*h, x.(neighbor)) }
+func (h *neighborHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// FarthestVertex returns the index of the vertex of the line farthest from the given point,
+// and its distance in ruler units. Ties are resolved with the lowest index,
+// and an empty line returns an index of -1 with a distance of 0.
+func (r Ruler) FarthestVertex(l Line, p Point) (int, float64) {
+	var maxDist float64
+	maxI := -1
+
+	for i := 0; i < len(l); i++ {
+		d := r.Distance(l[i], p)
+		if maxI == -1 || d > maxDist {
+			maxDist = d
+			maxI = i
+		}
+	}
+
+	return maxI, maxDist
+}
+
+// LineSlice returns the portion of the given line that lies between provided start
+// and end points (the points being snapped on the line).
+// An empty line returns an empty slice, and a line with a single point returns that point.
+func (r Ruler) LineSlice(start Point, end Point, l Line) Line {
+	if len(l) < 2 {
+		return r.CloneLine(l)
+	}
+
+	p1 := r.PointOnLine(l, start)
+	p2 := r.PointOnLine(l, end)
+
+	if p1.index > p2.index || (p1.index == p2.index && p1.t > p2.t) {
+		p1, p2 = p2, p1
+	}
+
+	var slice Line = []Point{p1.point}
+
+	left := p1.index + 1
+	right := p2.index
+
+	if l[left] != slice[0] && left <= right {
+		slice = append(slice, l[left])
+	}
+
+	for i := left + 1; i <= right; i++ {
+		slice = append(slice, l[i])
+	}
+
+	if l[right] != p2.point {
+		slice = append(slice, p2.point)
+	}
+
+	return slice
+}
+
+// LineSliceAlong returns the portion of the given line that lies between provided start
+// and end distances, in ruler units.
+func (r Ruler) LineSliceAlong(start float64, stop float64, l Line) Line {
+	var sum float64
+	var slice []Point
+
+	for i := 0; i < len(l)-1; i++ {
+		p0 := l[i]
+		p1 := l[i+1]
+		d := r.Distance(p0, p1)
+
+		sum += d
+
+		if sum > start && len(slice) == 0 {
+			slice = append(slice, interpolate(p0, p1, (start-(sum-d))/d))
+		}
+
+		if sum >= stop {
+			slice = append(slice, interpolate(p0, p1, (stop-(sum-d))/d))
 			return slice
 		}
 
-		if sum > start {
-			slice = append(slice, p1)
+		if sum > start {
+			slice = append(slice, p1)
+		}
+	}
+
+	return slice
+}
+
+// Densify returns a copy of the given line with evenly spaced points inserted in each segment
+// so that no segment is longer than maxDist, in ruler units. The original points are kept.
+// A maxDist lower than or equal to 0 returns an unchanged copy of the line.
+func (r Ruler) Densify(l Line, maxDist float64) Line {
+	if maxDist <= 0 || len(l) == 0 {
+		return r.CloneLine(l)
+	}
+
+	dense := Line{l[0]}
+	for i := 0; i < len(l)-1; i++ {
+		n := math.Ceil(r.Distance(l[i], l[i+1]) / maxDist)
+		for j := 1.; j < n; j++ {
+			dense = append(dense, interpolate(l[i], l[i+1], j/n))
+		}
+		dense = append(dense, l[i+1])
+	}
+	return dense
+}
+
+// SegmentIntersection returns the point where the segments a1-a2 and b1-b2 cross, and whether they do.
+// Segments touching at an endpoint intersect, while parallel and collinear segments never do,
+// even when they overlap.
+func (r Ruler) SegmentIntersection(a1 Point, a2 Point, b1 Point, b2 Point) (Point, bool) {
+	ax := (a2[0] - a1[0]) * r.kx
+	ay := (a2[1] - a1[1]) * r.ky
+	bx := (b2[0] - b1[0]) * r.kx
+	by := (b2[1] - b1[1]) * r.ky
+
+	// the cross product is compared to the segment lengths to ignore rounding errors
+	d := ax*by - ay*bx
+	if math.Abs(d) <= 1e-12*(ax*ax+ay*ay+bx*bx+by*by) {
+		return Point{}, false
+	}
+
+	dx := (b1[0] - a1[0]) * r.kx
+	dy := (b1[1] - a1[1]) * r.ky
+	t := (dx*by - dy*bx) / d
+	u := (dx*ay - dy*ax) / d
+
+	// a small tolerance avoids missing intersections at endpoints because of rounding errors
+	const eps = 1e-9
+	if t < -eps || t > 1+eps || u < -eps || u > 1+eps {
+		return Point{}, false
+	}
+
+	// segments touching at an endpoint return that exact endpoint
+	switch {
+	case math.Abs(u) <= eps:
+		return b1, true
+	case math.Abs(u-1) <= eps:
+		return b2, true
+	case math.Abs(t) <= eps:
+		return a1, true
+	case math.Abs(t-1) <= eps:
+		return a2, true
+	}
+
+	return interpolate(a1, a2, t), true
+}
+
+// LineIntersections returns all the points where the lines a and b cross, in the order they are found along a.
+// Points where the lines cross at a shared vertex are only returned once.
+// Each segment of a is checked against each segment of b, so the complexity is O(len(a) * len(b)).
+func (r Ruler) LineIntersections(a Line, b Line) []Point {
+	var intersections []Point
+
+	for i := 0; i < len(a)-1; i++ {
+		for j := 0; j < len(b)-1; j++ {
+			p, ok := r.SegmentIntersection(a[i], a[i+1], b[j], b[j+1])
+			if ok && !containsPoint(intersections, p) {
+				intersections = append(intersections, p)
+			}
+		}
+	}
+	return intersections
+}
+
+// CloneLine returns a copy of the given line that does not share its memory.
+func (r Ruler) CloneLine(l Line) Line {
+	clone := make(Line, len(l))
+	copy(clone, l)
+	return clone
+}
+
+// ClonePolygon returns a copy of the given polygon that does not share its memory.
+func (r Ruler) ClonePolygon(p Polygon) Polygon {
+	clone := make(Polygon, len(p))
+	for i, ring := range p {
+		clone[i] = r.CloneLine(ring)
+	}
+	return clone
+}
+
+// CloseRings returns a copy of the given polygon where the first point of every ring that is not closed
+// is appended to it. Empty rings are left empty.
+func (r Ruler) CloseRings(p Polygon) Polygon {
+	closed := r.ClonePolygon(p)
+	for i, ring := range closed {
+		if len(ring) > 0 && !r.IsClosed(ring) {
+			closed[i] = append(ring, ring[0])
+		}
+	}
+	return closed
+}
+
+// Reverse returns a copy of the given line with its points in reverse order.
+func (r Ruler) Reverse(l Line) Line {
+	reversed := make(Line, len(l))
+	for i, p := range l {
+		reversed[len(l)-1-i] = p
+	}
+	return reversed
+}
+
+// Translate returns a copy of the given line with every point moved by the given distance in ruler units
+// and bearing in degrees from north, like Destination, which preserves its shape.
+func (r Ruler) Translate(l Line, dist float64, bearing float64) Line {
+	translated := make(Line, len(l))
+	for i, p := range l {
+		translated[i] = r.Destination(p, dist, bearing)
+	}
+	return translated
+}
+
+// Scale returns a copy of the given line scaled by the given factor about the pivot point.
+// Scaling is done in the flat projection used by the ruler, so it is uniform in ruler units.
+func (r Ruler) Scale(l Line, pivot Point, factor float64) Line {
+	scaled := make(Line, len(l))
+	for i, p := range l {
+		dx := (p[0] - pivot[0]) * r.kx
+		dy := (p[1] - pivot[1]) * r.ky
+		scaled[i] = r.Offset(pivot, dx*factor, dy*factor)
+	}
+	return scaled
+}
+
+// TotalTurn returns the sum of the absolute bearing changes between consecutive segments of a line, in degrees.
+// Lines with fewer than three points return 0.
+func (r Ruler) TotalTurn(l Line) float64 {
+	var total float64
+
+	for i := 1; i < len(l)-1; i++ {
+		total += math.Abs(r.TurnAngle(l, i))
+	}
+	return total
+}
+
+// TurnAngle returns the signed change of bearing in degrees at the vertex of the line with the given index,
+// between the incoming and the outgoing segments. Right turns are positive and left turns are negative.
+// The endpoints and indexes outside of the line return 0.
+func (r Ruler) TurnAngle(l Line, i int) float64 {
+	if i <= 0 || i >= len(l)-1 {
+		return 0
+	}
+	return normalizeAngle(r.Bearing(l[i], l[i+1]) - r.Bearing(l[i-1], l[i]))
+}
+
+// ConvexHull returns the convex hull of the given points as a polygon with a single closed ring,
+// in counter-clockwise order. Fewer than three points return a polygon with a ring of these points,
+// and no points return an empty polygon.
+func (r Ruler) ConvexHull(pts []Point) Polygon {
+	if len(pts) == 0 {
+		return Polygon{}
+	}
+	if len(pts) < 3 {
+		return Polygon{append(Line{}, pts...)}
+	}
+
+	sorted := append(Line{}, pts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i][0] < sorted[j][0] || (sorted[i][0] == sorted[j][0] && sorted[i][1] < sorted[j][1])
+	})
+
+	// cross returns the z component of the cross product of oa and ob in projected space
+	cross := func(o, a, b Point) float64 {
+		return (a[0]-o[0])*r.kx*(b[1]-o[1])*r.ky - (a[1]-o[1])*r.ky*(b[0]-o[0])*r.kx
+	}
+
+	var hull Line
+	for _, p := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	for i, lower := len(sorted)-2, len(hull)+1; i >= 0; i-- {
+		p := sorted[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+
+	return Polygon{hull}
+}
+
+// FrechetDistance returns the discrete Fréchet distance between two lines, in ruler units.
+// It takes O(len(a) * len(b)) time and space. If either line is empty, the distance is infinite.
+func (r Ruler) FrechetDistance(a Line, b Line) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return math.Inf(1)
+	}
+
+	ca := make([][]float64, len(a))
+	for i := range a {
+		ca[i] = make([]float64, len(b))
+		for j := range b {
+			d := r.Distance(a[i], b[j])
+			switch {
+			case i == 0 && j == 0:
+				ca[i][j] = d
+			case i == 0:
+				ca[i][j] = math.Max(ca[i][j-1], d)
+			case j == 0:
+				ca[i][j] = math.Max(ca[i-1][j], d)
+			default:
+				ca[i][j] = math.Max(math.Min(ca[i-1][j], math.Min(ca[i-1][j-1], ca[i][j-1])), d)
+			}
 		}
 	}
 
-	return slice
+	return ca[len(a)-1][len(b)-1]
+}
+
+// HausdorffDistance returns the Hausdorff distance between two lines, in ruler units: the largest
+// distance from a vertex of one line to the other line. If either line is empty, the distance is infinite.
+func (r Ruler) HausdorffDistance(a Line, b Line) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return math.Inf(1)
+	}
+
+	var maxDist float64
+	for _, p := range a {
+		maxDist = math.Max(maxDist, r.DistanceToLine(b, p))
+	}
+	for _, p := range b {
+		maxDist = math.Max(maxDist, r.DistanceToLine(a, p))
+	}
+	return maxDist
+}
+
+// SimplifyVW returns a simplified copy of the given line with the Visvalingam-Whyatt algorithm:
+// the vertex forming the smallest triangle with its neighbors is removed, until all the remaining
+// triangles have an area larger than minArea, in squared ruler units. The endpoints are always kept.
+func (r Ruler) SimplifyVW(l Line, minArea float64) Line {
+	if len(l) < 3 {
+		return r.CloneLine(l)
+	}
+
+	prev := make([]int, len(l))
+	next := make([]int, len(l))
+	areas := make([]float64, len(l))
+	removed := make([]bool, len(l))
+	vertices := &vertexHeap{}
+	for i := range l {
+		prev[i] = i - 1
+		next[i] = i + 1
+		if i > 0 && i < len(l)-1 {
+			areas[i] = r.TriangleArea(l[i-1], l[i], l[i+1])
+			heap.Push(vertices, vertex{index: i, area: areas[i]})
+		}
+	}
+
+	for vertices.Len() > 0 {
+		v := heap.Pop(vertices).(vertex)
+		// vertices whose area changed since they were queued appear again with their new area
+		if removed[v.index] || v.area != areas[v.index] {
+			continue
+		}
+		if v.area > minArea {
+			break
+		}
+
+		removed[v.index] = true
+		p, n := prev[v.index], next[v.index]
+		next[p] = n
+		prev[n] = p
+		for _, i := range []int{p, n} {
+			if i > 0 && i < len(l)-1 {
+				areas[i] = r.TriangleArea(l[prev[i]], l[i], l[next[i]])
+				heap.Push(vertices, vertex{index: i, area: areas[i]})
+			}
+		}
+	}
+
+	var simplified Line
+	for i := 0; i < len(l); i = next[i] {
+		simplified = append(simplified, l[i])
+	}
+	return simplified
+}
+
+// vertex is a vertex index with the area of the triangle it forms with its neighbors, used by SimplifyVW.
+type vertex struct {
+	index int
+	area  float64
+}
+
+// vertexHeap is a priority queue of vertices, the smallest area first and the lowest index on ties.
+type vertexHeap []vertex
+
+func (h vertexHeap) Len() int { return len(h) }
+func (h vertexHeap) Less(i, j int) bool {
+	return h[i].area < h[j].area || (h[i].area == h[j].area && h[i].index < h[j].index)
+}
+func (h vertexHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *vertexHeap) Push(x interface{}) { *h = append(*h, x.(vertex)) }
+func (h *vertexHeap) Pop() interface{} {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+// Join returns a new line made of the points of a followed by the points of b.
+// If the last point of a is the first point of b, it is only kept once.
+func (r Ruler) Join(a Line, b Line) Line {
+	joined := r.CloneLine(a)
+	if len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[0] {
+		b = b[1:]
+	}
+	return append(joined, b...)
+}
+
+// JoinAligned returns a new line joining a and b like Join, except that b is reversed first
+// if its last point, rather than its first point, is the last point of a.
+func (r Ruler) JoinAligned(a Line, b Line) Line {
+	if len(a) > 0 && len(b) > 0 && a[len(a)-1] != b[0] && a[len(a)-1] == b[len(b)-1] {
+		b = r.Reverse(b)
+	}
+	return r.Join(a, b)
+}
+
+// SplitAtDistance splits the line at the given distance from its start, in ruler units, and returns
+// the two resulting lines, both containing the split point. A distance lower than or equal to 0 returns
+// an empty first line, and a distance greater than or equal to the line length returns an empty second line.
+func (r Ruler) SplitAtDistance(l Line, dist float64) (Line, Line) {
+	if dist <= 0 {
+		return Line{}, r.CloneLine(l)
+	}
+
+	var sum float64
+	for i := 0; i < len(l)-1; i++ {
+		p0 := l[i]
+		p1 := l[i+1]
+		d := r.Distance(p0, p1)
+		sum += d
+		if sum > dist {
+			p := interpolate(p0, p1, (dist-(sum-d))/d)
+			// a split exactly on a vertex does not repeat it
+			if p == p0 {
+				return r.CloneLine(l[:i+1]), r.CloneLine(l[i:])
+			}
+			before := append(r.CloneLine(l[:i+1]), p)
+			after := append(Line{p}, l[i+1:]...)
+			return before, after
+		}
+	}
+
+	return r.CloneLine(l), Line{}
+}
+
+// BoundingCircle returns the center and the radius in ruler units of the smallest circle containing
+// all the points of the line, using Welzl's algorithm in its iterative form. The points are not shuffled
+// to keep results deterministic, so the worst case is cubic, but typical lines are processed in linear time.
+// An empty line returns a zero Point and a radius of 0.
+func (r Ruler) BoundingCircle(l Line) (Point, float64) {
+	if len(l) == 0 {
+		return Point{}, 0
+	}
+
+	origin := l[0]
+	pts := make([][2]float64, len(l))
+	for i, p := range l {
+		pts[i] = [2]float64{(p[0] - origin[0]) * r.kx, (p[1] - origin[1]) * r.ky}
+	}
+
+	var c [2]float64
+	var radius float64
+	inside := func(p [2]float64) bool {
+		return math.Hypot(p[0]-c[0], p[1]-c[1]) <= radius*(1+1e-12)
+	}
+
+	for i := range pts {
+		if inside(pts[i]) {
+			continue
+		}
+		c, radius = pts[i], 0
+		for j := 0; j < i; j++ {
+			if inside(pts[j]) {
+				continue
+			}
+			c, radius = diameterCircle(pts[i], pts[j])
+			for k := 0; k < j; k++ {
+				if !inside(pts[k]) {
+					c, radius = circumCircle(pts[i], pts[j], pts[k])
+				}
+			}
+		}
+	}
+
+	return Point{origin[0] + c[0]/r.kx, origin[1] + c[1]/r.ky}, radius
+}
+
+// ClosestBetweenLines returns the closest points of the lines a and b, and the distance between them in ruler units.
+// If the lines cross, the first crossing found along a is returned with a distance of 0.
+// Each segment of a is compared with each segment of b, so the complexity is O(len(a) * len(b)).
+// If either line is empty, zero Points are returned with an infinite distance.
+func (r Ruler) ClosestBetweenLines(a Line, b Line) (Point, Point, float64) {
+	if len(a) == 0 || len(b) == 0 {
+		return Point{}, Point{}, math.Inf(1)
+	}
+
+	for i := 0; i < len(a)-1; i++ {
+		for j := 0; j < len(b)-1; j++ {
+			if p, ok := r.SegmentIntersection(a[i], a[i+1], b[j], b[j+1]); ok {
+				return p, p, 0
+			}
+		}
+	}
+
+	// segments that do not cross are closest at an endpoint of one of them
+	minDist := math.Inf(1)
+	var minA, minB Point
+	for _, p := range a {
+		if pol, d := r.pointOnLine(b, p); d < minDist {
+			minDist, minA, minB = d, p, pol.point
+		}
+	}
+	for _, p := range b {
+		if pol, d := r.pointOnLine(a, p); d < minDist {
+			minDist, minA, minB = d, pol.point, p
+		}
+	}
+
+	return minA, minB, math.Sqrt(minDist)
+}
+
+// OffsetLine returns a line parallel to the given line, at dist ruler units on its right side
+// (or on its left side if dist is negative), with mitered corners. Corners sharper than
+// maxMiterTurn are beveled instead, since their miter would be arbitrarily far from the line.
+// Lines with fewer than two distinct points are returned unchanged.
+func (r Ruler) OffsetLine(l Line, dist float64) Line {
+	var line Line
+	for i, p := range l {
+		if i == 0 || p != l[i-1] {
+			line = append(line, p)
+		}
+	}
+
+	if len(line) < 2 {
+		return r.CloneLine(l)
+	}
+
+	bearings := r.Bearings(line)
+	offset := Line{r.Destination(line[0], dist, bearings[0]+90)}
+	for i := 1; i < len(line)-1; i++ {
+		turn := normalizeAngle(bearings[i] - bearings[i-1])
+		if math.Abs(turn) > maxMiterTurn {
+			offset = append(offset,
+				r.Destination(line[i], dist, bearings[i-1]+90),
+				r.Destination(line[i], dist, bearings[i]+90))
+			continue
+		}
+		miter := dist / math.Cos(turn/2*math.Pi/180)
+		offset = append(offset, r.Destination(line[i], miter, bearings[i-1]+90+turn/2))
+	}
+	offset = append(offset, r.Destination(line[len(line)-1], dist, bearings[len(bearings)-1]+90))
+
+	return offset
+}
+
+// LabelAnchor returns the point located offset ruler units on the right side (or on the left side if offset
+// is negative) of the point at the given distance along the line, along with the bearing of the line there,
+// to be used as the rotation of a label.
+func (r Ruler) LabelAnchor(l Line, along float64, offset float64) (Point, float64) {
+	if len(l) < 2 {
+		return r.Along(l, along), 0
+	}
+
+	var sum float64
+	i := 0
+	for ; i < len(l)-2; i++ {
+		d := r.Distance(l[i], l[i+1])
+		if sum+d > along {
+			break
+		}
+		sum += d
+	}
+
+	bearing := r.Bearing(l[i], l[i+1])
+	return r.Destination(r.Along(l, along), offset, bearing+90), bearing
 }
 
 // BufferPoint returns a Bbox that contains the given point with a buffer margin given
@@ -293,6 +1966,102 @@ func (r Ruler) BufferPoint(p Point, buffer float64) Bbox {
 	}
 }
 
+// CirclePolygon returns a polygon approximating a circle of the given radius in ruler units around
+// the center, with a closed ring of steps points in counter-clockwise order. Steps lower than 3 are raised to 3.
+func (r Ruler) CirclePolygon(center Point, radius float64, steps int) Polygon {
+	if steps < 3 {
+		steps = 3
+	}
+
+	ring := make(Line, steps+1)
+	for i := 0; i < steps; i++ {
+		ring[i] = r.Destination(center, radius, -360*float64(i)/float64(steps))
+	}
+	ring[steps] = ring[0]
+
+	return Polygon{ring}
+}
+
+// BufferLine returns a polygon with a single closed ring, in counter-clockwise order, that contains all the
+// points within radius ruler units of the line. Joins and ends are rounded with arcs that would use
+// steps points for a full circle. A line with a single point returns a CirclePolygon.
+func (r Ruler) BufferLine(l Line, radius float64, steps int) Polygon {
+	if steps < 3 {
+		steps = 3
+	}
+
+	var line Line
+	for i, p := range l {
+		if i == 0 || p != l[i-1] {
+			line = append(line, p)
+		}
+	}
+
+	if len(line) == 0 {
+		return Polygon{}
+	}
+	if len(line) == 1 {
+		return r.CirclePolygon(line[0], radius, steps)
+	}
+
+	// walking both sides gives a clockwise ring, which is reversed
+	ring := r.bufferSide(line, radius, steps)
+	ring = append(ring, r.bufferSide(r.Reverse(line), radius, steps)...)
+	ring = append(ring, ring[0])
+
+	return Polygon{r.Reverse(ring)}
+}
+
+// bufferSide returns the left side of the buffer of a line, from its first to its last point,
+// followed by the rounded cap around the last point up to (but excluding) the start of the right side.
+func (r Ruler) bufferSide(l Line, radius float64, steps int) Line {
+	n := len(l)
+	bearings := r.Bearings(l)
+
+	side := Line{r.Destination(l[0], radius, bearings[0]-90)}
+	for j := 1; j < n-1; j++ {
+		end := r.Destination(l[j], radius, bearings[j-1]-90)
+		next := r.Destination(l[j], radius, bearings[j]-90)
+		turn := normalizeAngle(bearings[j] - bearings[j-1])
+
+		switch {
+		case turn > 0:
+			// the left side is on the outside of the turn: round the join
+			side = append(side, end)
+			side = append(side, r.arc(l[j], radius, bearings[j-1]-90, turn, steps)...)
+			side = append(side, next)
+		case turn < 0:
+			// the left side is on the inside of the turn: join the offset segments where they cross
+			start := r.Destination(l[j-1], radius, bearings[j-1]-90)
+			nextEnd := r.Destination(l[j+1], radius, bearings[j]-90)
+			if p, ok := r.SegmentIntersection(start, end, next, nextEnd); ok {
+				side = append(side, p)
+			} else {
+				side = append(side, end, next)
+			}
+		default:
+			side = append(side, end)
+		}
+	}
+
+	last := bearings[n-2]
+	side = append(side, r.Destination(l[n-1], radius, last-90))
+	side = append(side, r.arc(l[n-1], radius, last-90, 180, steps)...)
+
+	return side
+}
+
+// arc returns the points strictly between the start and end of the arc of a circle of the given radius
+// around the center, going clockwise by sweep degrees from the start bearing, so that a full circle has steps points.
+func (r Ruler) arc(center Point, radius float64, start float64, sweep float64, steps int) Line {
+	n := int(math.Ceil(sweep * float64(steps) / 360))
+	var arc Line
+	for i := 1; i < n; i++ {
+		arc = append(arc, r.Destination(center, radius, start+sweep*float64(i)/float64(n)))
+	}
+	return arc
+}
+
 // BufferBbox returns a Bbox that contains the given bbox with a buffer margin given
 // in ruler units.
 func (r Ruler) BufferBbox(b Bbox, buffer float64) Bbox {
@@ -315,9 +2084,316 @@ func (r Ruler) InsideBbox(p Point, b Bbox) bool {
 		p[1] <= b[3]
 }
 
+// ClosestOnBbox returns the point of the given bbox closest to the given point,
+// which is the point itself if it is inside the bbox.
+// Combined with Distance, it gives the distance from a point to a bbox.
+func (r Ruler) ClosestOnBbox(p Point, b Bbox) Point {
+	return Point{
+		math.Max(b[0], math.Min(b[2], p[0])),
+		math.Max(b[1], math.Min(b[3], p[1])),
+	}
+}
+
+// NormalizeLongitude returns the given longitude in degrees wrapped into the [-180, 180] range.
+func NormalizeLongitude(lon float64) float64 {
+	return normalizeAngle(lon)
+}
+
+// EmptyBbox returns a Bbox that contains no point, to be grown with ExtendBbox.
+func EmptyBbox() Bbox {
+	return Bbox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+}
+
+// ExtendBbox returns the smallest Bbox that contains both the given bbox and the given point.
+func (r Ruler) ExtendBbox(b Bbox, p Point) Bbox {
+	return Bbox{
+		math.Min(b[0], p[0]),
+		math.Min(b[1], p[1]),
+		math.Max(b[2], p[0]),
+		math.Max(b[3], p[1]),
+	}
+}
+
+// LineBbox returns the smallest Bbox that contains all the points of the given line.
+// An empty line returns a zero Bbox.
+func (r Ruler) LineBbox(l Line) Bbox {
+	if len(l) == 0 {
+		return Bbox{}
+	}
+
+	b := EmptyBbox()
+	for _, p := range l {
+		b = r.ExtendBbox(b, p)
+	}
+	return b
+}
+
+// PolygonBbox returns the smallest Bbox that contains all the points of the given polygon.
+// Since holes lie inside the outer ring, only the outer ring is scanned. An empty polygon returns a zero Bbox.
+func (r Ruler) PolygonBbox(p Polygon) Bbox {
+	if len(p) == 0 {
+		return Bbox{}
+	}
+	return r.LineBbox(p[0])
+}
+
+// BboxCenter returns the point at the center of the given bbox.
+func (r Ruler) BboxCenter(b Bbox) Point {
+	return Point{(b[0] + b[2]) / 2, (b[1] + b[3]) / 2}
+}
+
+// BboxArea returns the area of the given bbox, in squared ruler units.
+func (r Ruler) BboxArea(b Bbox) float64 {
+	return (b[2] - b[0]) * r.kx * (b[3] - b[1]) * r.ky
+}
+
+// BboxIntersects returns a boolean value, whether the two given bboxes overlap.
+// Bboxes that only touch on their edges or corners overlap.
+func (r Ruler) BboxIntersects(a Bbox, b Bbox) bool {
+	return a[0] <= b[2] &&
+		b[0] <= a[2] &&
+		a[1] <= b[3] &&
+		b[1] <= a[3]
+}
+
+// BboxContains returns a boolean value, whether the inner bbox lies entirely inside the outer bbox.
+func (r Ruler) BboxContains(outer Bbox, inner Bbox) bool {
+	return inner[0] >= outer[0] &&
+		inner[1] >= outer[1] &&
+		inner[2] <= outer[2] &&
+		inner[3] <= outer[3]
+}
+
+// ClipToBbox returns the parts of the given line that lie inside the given bbox, as separate lines
+// that start and end where the line enters and exits the bbox. Parts outside the bbox are dropped,
+// as well as places where the line only touches the bbox. A line with a single point inside the bbox
+// returns that point.
+func (r Ruler) ClipToBbox(l Line, b Bbox) []Line {
+	var parts []Line
+	var part Line
+
+	for i := 0; i < len(l)-1; i++ {
+		p0 := l[i]
+		p1 := l[i+1]
+		t0, t1, ok := clipSegment(p0, p1, b)
+		if !ok {
+			if len(part) > 1 {
+				parts = append(parts, part)
+			}
+			part = nil
+			continue
+		}
+
+		start, end := p0, p1
+		if t0 >= 1 {
+			start = p1
+		} else if t0 > 0 {
+			start = interpolate(p0, p1, t0)
+		}
+		if t1 < 1 {
+			end = interpolate(p0, p1, t1)
+		}
+
+		if len(part) == 0 || t0 > 0 {
+			if len(part) > 1 {
+				parts = append(parts, part)
+			}
+			part = Line{start}
+		}
+		// a segment that only touches the bbox at its end adds nothing to the part
+		if end != part[len(part)-1] {
+			part = append(part, end)
+		}
+
+		if t1 < 1 {
+			if len(part) > 1 {
+				parts = append(parts, part)
+			}
+			part = nil
+		}
+	}
+
+	if len(part) > 1 {
+		parts = append(parts, part)
+	}
+	if len(l) == 1 && r.InsideBbox(l[0], b) {
+		parts = append(parts, Line{l[0]})
+	}
+
+	return parts
+}
+
+// BboxToLine returns the closed ring of the given bbox as a line of five points,
+// in counter-clockwise order starting from the southwest corner.
+func (r Ruler) BboxToLine(b Bbox) Line {
+	return Line{
+		Point{b[0], b[1]},
+		Point{b[2], b[1]},
+		Point{b[2], b[3]},
+		Point{b[0], b[3]},
+		Point{b[0], b[1]},
+	}
+}
+
+// BboxToPolygon returns a polygon with the closed ring of the given bbox as its outer ring.
+func (r Ruler) BboxToPolygon(b Bbox) Polygon {
+	return Polygon{r.BboxToLine(b)}
+}
+
 // interpolate returns a point located at the given proportion t between the points a and b.
 func interpolate(a Point, b Point, t float64) Point {
 	dx := b[0] - a[0]
 	dy := b[1] - a[1]
 	return Point{a[0] + dx*t, a[1] + dy*t}
 }
+
+// interpolateAcross is like interpolate, but follows the shortest way around the globe
+// when the points are more than 180 degrees of longitude apart.
+func interpolateAcross(a Point, b Point, t float64) Point {
+	dx := wrapLongitude(b[0] - a[0])
+	dy := b[1] - a[1]
+	return Point{wrapCrossing(a[0]+dx*t, b[0]-a[0]), a[1] + dy*t}
+}
+
+// wrapCrossing wraps the given longitude into the [-180, 180] range only if it was computed along a segment
+// whose raw longitude difference crosses the antimeridian, so that lines using longitudes beyond 180 degrees
+// keep their convention.
+func wrapCrossing(lon float64, delta float64) float64 {
+	if delta > 180 || delta < -180 {
+		return wrapLongitude(lon)
+	}
+	return lon
+}
+
+// wrapLongitude returns the given longitude, or difference of longitudes, in degrees wrapped into the [-180, 180]
+// range if it is outside of it. Differences of more than 180 degrees are assumed to cross the antimeridian.
+func wrapLongitude(lon float64) float64 {
+	if lon > 180 || lon < -180 {
+		return normalizeAngle(lon)
+	}
+	return lon
+}
+
+// onSegment returns a boolean value, whether the point p lies exactly on the segment between a and b.
+func onSegment(p Point, a Point, b Point) bool {
+	if (b[0]-a[0])*(p[1]-a[1])-(b[1]-a[1])*(p[0]-a[0]) != 0 {
+		return false
+	}
+	return p[0] >= math.Min(a[0], b[0]) && p[0] <= math.Max(a[0], b[0]) &&
+		p[1] >= math.Min(a[1], b[1]) && p[1] <= math.Max(a[1], b[1])
+}
+
+// containsPoint returns a boolean value, whether the given points contain the point p.
+func containsPoint(points []Point, p Point) bool {
+	for _, q := range points {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAngle returns the given angle in degrees wrapped into the [-180, 180] range.
+func normalizeAngle(a float64) float64 {
+	a = math.Mod(a, 360)
+	if a > 180 {
+		a -= 360
+	} else if a < -180 {
+		a += 360
+	}
+	return a
+}
+
+// clipSegment returns the range [t0, t1] of the proportions along the segment between a and b
+// that lie inside the given bbox, using the Liang-Barsky algorithm, and whether that range is not empty.
+// Since the flat projection of the ruler is a scaling of the coordinates, clipping is done in degrees.
+func clipSegment(a Point, b Point, bbox Bbox) (float64, float64, bool) {
+	t0, t1 := 0., 1.
+	dx := b[0] - a[0]
+	dy := b[1] - a[1]
+
+	for _, edge := range [4][2]float64{
+		{-dx, a[0] - bbox[0]},
+		{dx, bbox[2] - a[0]},
+		{-dy, a[1] - bbox[1]},
+		{dy, bbox[3] - a[1]},
+	} {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return 0, 0, false
+			}
+			continue
+		}
+
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+		if t0 > t1 {
+			return 0, 0, false
+		}
+	}
+
+	return t0, t1, true
+}
+
+// openRing returns the ring without its closing point, if it has one.
+func openRing(ring Line) Line {
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		return ring[:len(ring)-1]
+	}
+	return ring
+}
+
+// centralAngle returns the great-circle angle in radians between two points, with the haversine formula.
+func centralAngle(a Point, b Point) float64 {
+	lat1 := a[1] * math.Pi / 180
+	lat2 := b[1] * math.Pi / 180
+	sinLat := math.Sin((lat2 - lat1) / 2)
+	sinLon := math.Sin((b[0] - a[0]) * math.Pi / 180 / 2)
+	h := sinLat*sinLat + math.Cos(lat1)*math.Cos(lat2)*sinLon*sinLon
+	return 2 * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// ringSum returns the shoelace sum of a ring in squared degrees, which is twice its area,
+// positive if the ring is clockwise and negative if it is counter-clockwise.
+func ringSum(ring Line) float64 {
+	var sum float64
+	for j, len, k := 0, len(ring), len(ring)-1; j < len; k, j = j, j+1 {
+		sum += (ring[j][0] - ring[k][0]) * (ring[j][1] + ring[k][1])
+	}
+	return sum
+}
+
+// diameterCircle returns the center and radius of the circle whose diameter is the segment between a and b.
+func diameterCircle(a [2]float64, b [2]float64) ([2]float64, float64) {
+	return [2]float64{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2}, math.Hypot(b[0]-a[0], b[1]-a[1]) / 2
+}
+
+// circumCircle returns the center and radius of the circle going through a, b and c.
+// If the points are collinear, the largest circle whose diameter is a pair of them is returned.
+func circumCircle(a [2]float64, b [2]float64, c [2]float64) ([2]float64, float64) {
+	bx, by := b[0]-a[0], b[1]-a[1]
+	cx, cy := c[0]-a[0], c[1]-a[1]
+	d := 2 * (bx*cy - by*cx)
+
+	if d == 0 {
+		center, radius := diameterCircle(a, b)
+		if c2, r2 := diameterCircle(a, c); r2 > radius {
+			center, radius = c2, r2
+		}
+		if c3, r3 := diameterCircle(b, c); r3 > radius {
+			center, radius = c3, r3
+		}
+		return center, radius
+	}
+
+	b2 := bx*bx + by*by
+	c2 := cx*cx + cy*cy
+	ux := (cy*b2 - by*c2) / d
+	uy := (bx*c2 - cx*b2) / d
+	return [2]float64{a[0] + ux, a[1] + uy}, math.Hypot(ux, uy)
+}
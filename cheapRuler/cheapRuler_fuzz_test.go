@@ -0,0 +1,42 @@
+//go:build go1.18
+// +build go1.18
+
+package cheapRuler
+
+import (
+	"math"
+	"testing"
+)
+
+func FuzzPointOnLine(f *testing.F) {
+	f.Add(2.3503875, 48.863598, 2.3501086, 48.8627334, 2.3485958, 48.862747, 2.350, 48.861)
+	f.Add(2.3503875, 48.863598, 2.3503875, 48.863598, 2.3485958, 48.862747, 2.350, 48.861)
+	f.Add(2.3503875, 48.863598, 2.3485958, 48.862747, 2.3485958, 48.862747, 2.340, 48.861)
+	f.Add(0., 0., 1e-200, 1e-200, 1e-200, 2e-200, 1e-200, 0.)
+
+	ruler, _ := NewRuler(48.8629, "meters")
+
+	f.Fuzz(func(t *testing.T, x0, y0, x1, y1, x2, y2, px, py float64) {
+		line := Line{Point{x0, y0}, Point{x1, y1}, Point{x2, y2}}
+		p := Point{px, py}
+		for _, q := range append(line, p) {
+			if !(math.Abs(q[0]) <= 180 && math.Abs(q[1]) <= 90) {
+				t.Skip()
+			}
+		}
+
+		pol := ruler.PointOnLine(line, p)
+
+		if math.IsNaN(pol.t) || pol.t < 0 || pol.t > 1 {
+			t.Fatalf("%+v has t outside of [0, 1]", pol)
+		}
+		if pol.index < 0 || pol.index >= len(line)-1 {
+			t.Fatalf("%+v has an invalid index", pol)
+		}
+		for _, c := range pol.point {
+			if math.IsNaN(c) || math.IsInf(c, 0) {
+				t.Fatalf("%+v is not finite", pol)
+			}
+		}
+	})
+}
@@ -1,7 +1,9 @@
 package cheapRuler
 
 import (
+	"encoding/json"
 	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -32,196 +34,3063 @@ func TestNewRuler(t *testing.T) {
 	t.Log("OK", ruler)
 }
 
-func TestDistance(t *testing.T) {
-	t.Log("ruler distance is correct")
+func TestCheapRulerInterface(t *testing.T) {
+	t.Log("Ruler implements the CheapRuler interface")
 
 	ruler, _ := NewRuler(48.8629, "meters")
-	a := [2]float64{2.344808, 48.862851}
-	b := [2]float64{2.352790, 48.862907}
-	distance := ruler.Distance(a, b)
-	expected := 585.71
+	var cheapRuler CheapRuler = ruler
+	a := Point{2.344808, 48.862851}
 
-	if math.Abs(distance-expected) > 1e-2 {
-		t.Fatalf("%f != %f", distance, expected)
+	if offset := cheapRuler.Offset(a, 1., -2.); offset != ruler.Offset(a, 1., -2.) {
+		t.Fatalf("%+v != %+v", offset, ruler.Offset(a, 1., -2.))
 	}
 
-	t.Log("OK", distance)
+	t.Log("OK", cheapRuler)
 }
 
-func TestLineDistance(t *testing.T) {
-	t.Log("ruler line distance is correct")
+func TestNewRulerFromTile(t *testing.T) {
+	t.Log("NewRulerFromTile uses the latitude of the tile center")
+
+	tiles := []struct {
+		y, z int
+		lat  float64
+	}{
+		{0, 0, 0},
+		{0, 1, 66.51326044311186},
+		{1, 1, -66.51326044311186},
+		{1, 2, 40.97989806962013},
+		{5800, 14, 46.43028524083995},
+	}
+
+	for _, tile := range tiles {
+		ruler, err := NewRulerFromTile(tile.y, tile.z, "kilometers")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, _ := NewRuler(tile.lat, "kilometers")
+
+		if math.Abs(ruler.kx-expected.kx) > 1e-9 || math.Abs(ruler.ky-expected.ky) > 1e-9 {
+			t.Fatalf("%+v != %+v", ruler, expected)
+		}
+	}
+
+	if _, err := NewRulerFromTile(0, -1, "kilometers"); err == nil {
+		t.Fatalf("negative zoom level should return an error")
+	}
+
+	if _, err := NewRulerFromTile(4, 2, "kilometers"); err == nil {
+		t.Fatalf("out of range tile should return an error")
+	}
+
+	if _, err := NewRulerFromTile(-1, 2, "kilometers"); err == nil {
+		t.Fatalf("negative tile should return an error")
+	}
+
+	t.Log("OK")
+}
+
+func TestNewRulerFromPoint(t *testing.T) {
+	t.Log("NewRulerFromPoint uses the latitude of the point")
+
+	ruler, err := NewRulerFromPoint(Point{2.344808, 48.8629}, "meters")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := NewRuler(48.8629, "meters")
+	if ruler != expected {
+		t.Fatalf("%+v != %+v", ruler, expected)
+	}
+
+	ruler, err = NewRulerFromPoint(Point{2.344808, 48.8629}, "parsecs")
+	expected, _ = NewRuler(48.8629, "kilometers")
+	if err == nil || ruler != expected {
+		t.Fatalf("invalid unit should fall back to kilometers with an error")
+	}
+
+	t.Log("OK", ruler)
+}
+
+func TestRegisterUnit(t *testing.T) {
+	t.Log("RegisterUnit adds and overrides units")
+
+	if err := RegisterUnit("furlongs", 1000/201.168); err != nil {
+		t.Fatal(err)
+	}
+
+	ruler, err := NewRuler(48.8629, "furlongs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meters, _ := NewRuler(48.8629, "meters")
+	if math.Abs(ruler.kx*201.168-meters.kx) > 1e-6 {
+		t.Fatalf("%f != %f", ruler.kx*201.168, meters.kx)
+	}
+
+	if err := RegisterUnit("furlongs", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	ruler, _ = NewRuler(48.8629, "furlongs")
+	kilometers, _ := NewRuler(48.8629, "kilometers")
+	if math.Abs(ruler.kx-5*kilometers.kx) > 1e-9 {
+		t.Fatalf("%f != %f", ruler.kx, 5*kilometers.kx)
+	}
+
+	for _, scale := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		if err := RegisterUnit("chains", scale); err == nil {
+			t.Fatalf("scale %f should be rejected", scale)
+		}
+	}
+
+	if err := RegisterUnit("", 1); err == nil {
+		t.Fatalf("empty unit name should be rejected")
+	}
+
+	if _, ok := unitScale("chains"); ok {
+		t.Fatalf("rejected units should not be registered")
+	}
+
+	t.Log("OK", ruler)
+}
+
+func TestConvertUnits(t *testing.T) {
+	t.Log("ConvertUnits is correct")
+
+	miles, err := ConvertUnits(1, "kilometers", "miles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(miles-0.621371) > 1e-6 {
+		t.Fatalf("%f != %f", miles, 0.621371)
+	}
+
+	feet, _ := ConvertUnits(123.4, "meters", "feet")
+	meters, _ := ConvertUnits(feet, "feet", "meters")
+
+	if math.Abs(meters-123.4) > 1e-9 {
+		t.Fatalf("%f != %f", meters, 123.4)
+	}
+
+	if _, err := ConvertUnits(1, "parsecs", "meters"); err == nil {
+		t.Fatalf("invalid unit should return an error")
+	}
+
+	if _, err := ConvertUnits(1, "meters", "parsecs"); err == nil {
+		t.Fatalf("invalid unit should return an error")
+	}
+
+	t.Log("OK", miles)
+}
+
+func TestMultipliers(t *testing.T) {
+	t.Log("ruler multipliers getters are correct")
+
+	ruler, _ := NewRuler(42.0, "kilometers")
+
+	if ruler.Kx() != ruler.kx || ruler.Ky() != ruler.ky {
+		t.Fatalf("%f, %f != %+v", ruler.Kx(), ruler.Ky(), ruler)
+	}
+
+	t.Log("OK", ruler.Kx(), ruler.Ky())
+}
+
+func TestLatAndUnit(t *testing.T) {
+	t.Log("ruler latitude and unit getters are correct")
 
 	ruler, _ := NewRuler(48.8629, "miles")
-	distance := ruler.LineDistance(testLine)
-	expected := 0.220571
 
-	if math.Abs(distance-expected) > 1e-2 {
-		t.Fatalf("%f != %f", distance, expected)
+	if ruler.Lat() != 48.8629 || ruler.Unit() != "miles" {
+		t.Fatalf("%f, %s != %f, %s", ruler.Lat(), ruler.Unit(), 48.8629, "miles")
 	}
 
-	t.Log("OK", distance)
+	ruler, _ = NewRuler(48.8629, "parsecs")
+
+	if ruler.Unit() != "kilometers" {
+		t.Fatalf("%s != %s", ruler.Unit(), "kilometers")
+	}
+
+	t.Log("OK", ruler.Lat(), ruler.Unit())
 }
 
-func TestBearing(t *testing.T) {
-	t.Log("ruler bearing is correct")
+func TestWithUnit(t *testing.T) {
+	t.Log("ruler with unit is correct")
 
 	ruler, _ := NewRuler(48.8629, "miles")
-	a := [2]float64{2.344808, 48.862851}
-	b := [2]float64{2.352790, 48.862907}
-	bearing := ruler.Bearing(a, b)
-	expected := 89.39
+	feet, err := ruler.WithUnit("feet")
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if math.Abs(bearing-expected) > 1e-2 {
-		t.Fatalf("%f != %f", bearing, expected)
+	expected, _ := NewRuler(48.8629, "feet")
+	if math.Abs(feet.kx-expected.kx) > 1e-6 || math.Abs(feet.ky-expected.ky) > 1e-6 ||
+		feet.Lat() != expected.Lat() || feet.Unit() != expected.Unit() {
+		t.Fatalf("%+v != %+v", feet, expected)
 	}
 
-	t.Log("OK", bearing)
+	kilometers, err := ruler.WithUnit("parsecs")
+	expected, _ = NewRuler(48.8629, "kilometers")
+	if err == nil || math.Abs(kilometers.kx-expected.kx) > 1e-9 || kilometers.Unit() != "kilometers" {
+		t.Fatalf("invalid unit should fall back to kilometers with an error")
+	}
+
+	var unmarshalled Ruler
+	if err := json.Unmarshal([]byte(`{"lat":48.8629,"unit":"feet"}`), &unmarshalled); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []Ruler{feet, expected, kilometers, unmarshalled} {
+		_, ky := multipliers(r.Lat())
+		if math.Abs(r.scale()-r.ky/ky) > 1e-9*r.scale() {
+			t.Fatalf("%f != %f", r.scale(), r.ky/ky)
+		}
+	}
+
+	t.Log("OK", feet)
 }
 
-func TestOffset(t *testing.T) {
-	t.Log("ruler offset is correct")
+func TestRulerJSON(t *testing.T) {
+	t.Log("ruler JSON round trip is correct")
 
 	ruler, _ := NewRuler(48.8629, "miles")
-	a := [2]float64{2.344808, 48.862851}
-	offset := ruler.Offset(a, 1., -2.)
-	expected := [2]float64{2.366741, 48.833907}
+	data, err := json.Marshal(ruler)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if math.Abs(offset[0]-expected[0]) > 1e-5 || math.Abs(offset[1]-expected[1]) > 1e-5 {
-		t.Fatalf("%+v != %+v", offset, expected)
+	expected := `{"lat":48.8629,"unit":"miles"}`
+	if string(data) != expected {
+		t.Fatalf("%s != %s", data, expected)
 	}
 
-	t.Log("OK", offset)
+	var decoded Ruler
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != ruler {
+		t.Fatalf("%+v != %+v", decoded, ruler)
+	}
+
+	if err := json.Unmarshal([]byte(`{"lat":48.8629,"unit":"parsecs"}`), &decoded); err == nil {
+		t.Fatalf("invalid unit should return an error")
+	}
+
+	t.Log("OK", string(data))
 }
 
-func TestDestination(t *testing.T) {
-	t.Log("ruler destination is correct")
+func TestCheckPoint(t *testing.T) {
+	t.Log("ruler accuracy checks are correct")
 
 	ruler, _ := NewRuler(48.8629, "meters")
-	a := [2]float64{2.344808, 48.862851}
-	destination := ruler.Destination(a, 1., 30.)
-	expected := [2]float64{2.344814, 48.862858}
 
-	if math.Abs(destination[0]-expected[0]) > 1e-5 || math.Abs(destination[1]-expected[1]) > 1e-5 {
-		t.Fatalf("%+v != %+v", destination, expected)
+	if ruler.AccurateWithin() != 500000 {
+		t.Fatalf("%f != %f", ruler.AccurateWithin(), 500000.)
 	}
 
-	t.Log("OK", destination)
+	if !ruler.CheckPoint(Point{2.35, 48.8629}) || !ruler.CheckPoint(Point{-3.0, 51.5}) {
+		t.Fatalf("points near the ruler latitude should be accurate")
+	}
+
+	if ruler.CheckPoint(Point{2.35, 40.4}) || ruler.CheckPoint(Point{2.35, 60.0}) {
+		t.Fatalf("points far from the ruler latitude should not be accurate")
+	}
+
+	t.Log("OK", ruler.AccurateWithin())
 }
 
-func TestAlong(t *testing.T) {
-	t.Log("ruler along is correct")
+func TestProject(t *testing.T) {
+	t.Log("ruler project and unproject are correct")
 
 	ruler, _ := NewRuler(48.8629, "meters")
-	along := ruler.Along(testLine, 150.)
-	expected := [2]float64{2.349404, 48.862739}
+	a := Point{2.344808, 48.862851}
+	b := Point{2.352790, 48.862907}
 
-	if math.Abs(along[0]-expected[0]) > 1e-5 || math.Abs(along[1]-expected[1]) > 1e-5 {
-		t.Fatalf("%+v != %+v", along, expected)
+	ax, ay := ruler.Project(a)
+	bx, by := ruler.Project(b)
+	if d := math.Hypot(bx-ax, by-ay); math.Abs(d-ruler.Distance(a, b)) > 1e-6 {
+		t.Fatalf("%f != %f", d, ruler.Distance(a, b))
 	}
 
-	t.Log("OK", along)
+	p := ruler.Unproject(ax, ay)
+	if math.Abs(p[0]-a[0]) > 1e-12 || math.Abs(p[1]-a[1]) > 1e-12 {
+		t.Fatalf("%+v != %+v", p, a)
+	}
+
+	t.Log("OK", ax, ay)
 }
 
-func TestPointOnLine(t *testing.T) {
-	t.Log("ruler pointOnLine is correct")
+func TestDistance(t *testing.T) {
+	t.Log("ruler distance is correct")
 
 	ruler, _ := NewRuler(48.8629, "meters")
-	pol := ruler.PointOnLine(testLine, [2]float64{2.350, 48.861})
-	var expected PointOnLine = PointOnLine{
-		point: [2]float64{2.3500358, 48.862734},
-		index: 1,
-		t:     0.048116,
-	}
+	a := [2]float64{2.344808, 48.862851}
+	b := [2]float64{2.352790, 48.862907}
+	distance := ruler.Distance(a, b)
+	expected := 585.71
 
-	if math.Abs(pol.point[0]-expected.point[0]) > 1e-5 ||
-		math.Abs(pol.point[1]-expected.point[1]) > 1e-5 ||
-		pol.index != expected.index ||
-		math.Abs(pol.t-expected.t) > 1e-5 {
-		t.Fatalf("%+v != %+v", pol, expected)
+	if math.Abs(distance-expected) > 1e-2 {
+		t.Fatalf("%f != %f", distance, expected)
 	}
 
-	t.Log("OK", pol)
+	t.Log("OK", distance)
 }
 
-func TestLineSlice(t *testing.T) {
-	t.Log("ruler line slice is correct")
+func TestSquaredDistance(t *testing.T) {
+	t.Log("ruler squared distance is correct")
 
-	ruler, _ := NewRuler(48.8629, "miles")
-	a := Point{2.350054, 48.863154}
-	b := Point{2.347555, 48.862145}
-	slice := ruler.LineSlice(a, b, testLine)
-	sliceDistance := ruler.LineDistance(slice)
-	expected := 0.164593
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.344808, 48.862851}
+	b := Point{2.352790, 48.862907}
+	squared := ruler.SquaredDistance(a, b)
+	distance := ruler.Distance(a, b)
 
-	if math.Abs(sliceDistance-expected) > 1e-5 {
-		t.Fatalf("%f != %f", sliceDistance, expected)
+	if distance != math.Sqrt(squared) {
+		t.Fatalf("%f != %f", distance, math.Sqrt(squared))
 	}
 
-	t.Log("OK", slice)
+	t.Log("OK", squared)
 }
 
-func TestLineSliceAlong(t *testing.T) {
-	t.Log("ruler line slice along is correct")
+func BenchmarkNearestSquaredDistance(b *testing.B) {
+	ruler, _ := NewRuler(48.8629, "meters")
+	p := Point{2.3484, 48.8625}
 
-	ruler, _ := NewRuler(48.8629, "miles")
-	slice := ruler.LineSliceAlong(0.05, 0.15, testLine)
-	sliceDistance := ruler.LineDistance(slice)
-	expected := 0.1
+	for i := 0; i < b.N; i++ {
+		minDist := math.Inf(1)
+		for _, q := range testLine {
+			if d := ruler.SquaredDistance(p, q); d < minDist {
+				minDist = d
+			}
+		}
+	}
+}
 
-	if math.Abs(sliceDistance-expected) > 1e-5 {
-		t.Fatalf("%f != %f", sliceDistance, expected)
+func BenchmarkNearestDistance(b *testing.B) {
+	ruler, _ := NewRuler(48.8629, "meters")
+	p := Point{2.3484, 48.8625}
+
+	for i := 0; i < b.N; i++ {
+		minDist := math.Inf(1)
+		for _, q := range testLine {
+			if d := ruler.Distance(p, q); d < minDist {
+				minDist = d
+			}
+		}
 	}
+}
 
-	t.Log("OK", slice)
+func TestDistances(t *testing.T) {
+	t.Log("ruler distances are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	distances, err := ruler.Distances(testLine[:len(testLine)-1], testLine[1:])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, distance := range distances {
+		expected := ruler.Distance(testLine[i], testLine[i+1])
+		if distance != expected {
+			t.Fatalf("%f != %f", distance, expected)
+		}
+	}
+
+	if _, err := ruler.Distances(testLine, testLine[1:]); err == nil {
+		t.Fatalf("slices of different lengths should return an error")
+	}
+
+	t.Log("OK", distances)
 }
 
-func TestBufferPoint(t *testing.T) {
-	t.Log("ruler buffer point is correct")
+func TestDistanceMatrix(t *testing.T) {
+	t.Log("ruler distance matrix is correct")
 
 	ruler, _ := NewRuler(48.8629, "meters")
-	a := Point{2.350054, 48.863154}
-	bbox := ruler.BufferPoint(a, 12)
-	expected := Bbox{2.349946, 48.862990, 2.350162, 48.863318}
+	a := Point{2.350, 48.862}
+	pts := []Point{a, ruler.Offset(a, 300, 0), ruler.Offset(a, 0, 400)}
+	matrix := ruler.DistanceMatrix(pts)
 
-	if math.Abs(bbox[0]-expected[0]) > 1e-5 ||
-		math.Abs(bbox[1]-expected[1]) > 1e-5 ||
-		math.Abs(bbox[2]-expected[2]) > 1e-5 ||
-		math.Abs(bbox[3]-expected[3]) > 1e-5 {
-		t.Fatalf("%f != %f", bbox, expected)
+	if len(matrix) != len(pts) {
+		t.Fatalf("%d != %d", len(matrix), len(pts))
 	}
 
-	t.Log("OK", bbox)
+	for i := range pts {
+		if len(matrix[i]) != len(pts) {
+			t.Fatalf("%d != %d", len(matrix[i]), len(pts))
+		}
+		if matrix[i][i] != 0 {
+			t.Fatalf("%f != 0", matrix[i][i])
+		}
+		for j := range pts {
+			if matrix[i][j] != matrix[j][i] {
+				t.Fatalf("%f != %f", matrix[i][j], matrix[j][i])
+			}
+			if expected := ruler.Distance(pts[i], pts[j]); math.Abs(matrix[i][j]-expected) > 1e-9 {
+				t.Fatalf("%f != %f", matrix[i][j], expected)
+			}
+		}
+	}
+
+	if math.Abs(matrix[1][2]-500) > 1e-6 {
+		t.Fatalf("%f != %f", matrix[1][2], 500.)
+	}
+
+	if empty := ruler.DistanceMatrix([]Point{}); len(empty) != 0 {
+		t.Fatalf("%+v should be empty", empty)
+	}
+
+	t.Log("OK", matrix)
 }
 
-func TestBufferBbox(t *testing.T) {
-	t.Log("ruler buffer bbox is correct")
+func BenchmarkDistances(b *testing.B) {
+	ruler, _ := NewRuler(48.8629, "meters")
+	from := testLine[:len(testLine)-1]
+	to := testLine[1:]
+
+	for i := 0; i < b.N; i++ {
+		ruler.Distances(from, to)
+	}
+}
 
+func BenchmarkDistanceLoop(b *testing.B) {
 	ruler, _ := NewRuler(48.8629, "meters")
-	a := Bbox{2.349946, 48.862990, 2.350162, 48.863318}
-	bbox := ruler.BufferBbox(a, 12)
-	expected := Bbox{2.349838, 48.862826, 2.350270, 48.863482}
+	from := testLine[:len(testLine)-1]
+	to := testLine[1:]
 
-	if math.Abs(bbox[0]-expected[0]) > 1e-5 ||
-		math.Abs(bbox[1]-expected[1]) > 1e-5 ||
-		math.Abs(bbox[2]-expected[2]) > 1e-5 ||
-		math.Abs(bbox[3]-expected[3]) > 1e-5 {
-		t.Fatalf("%f != %f", bbox, expected)
+	for i := 0; i < b.N; i++ {
+		distances := make([]float64, len(from))
+		for j := range from {
+			distances[j] = ruler.Distance(from[j], to[j])
+		}
 	}
+}
 
-	t.Log("OK", bbox)
+func TestHaversineDistance(t *testing.T) {
+	t.Log("ruler haversine distance is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.344808, 48.862851}
+	b := Point{2.352790, 48.862907}
+	distance := ruler.HaversineDistance(a, b)
+
+	if math.Abs(distance-ruler.Distance(a, b))/distance > 5e-3 {
+		t.Fatalf("%f should be close to %f", distance, ruler.Distance(a, b))
+	}
+
+	newYork := Point{-74.0060, 40.7128}
+	distance = ruler.HaversineDistance(a, newYork)
+	expected := 5837000.
+
+	if math.Abs(distance-expected)/expected > 5e-3 {
+		t.Fatalf("%f != %f", distance, expected)
+	}
+
+	if math.Abs(distance-ruler.Distance(a, newYork))/distance < 1e-2 {
+		t.Fatalf("%f should diverge from %f", distance, ruler.Distance(a, newYork))
+	}
+
+	t.Log("OK", distance)
 }
 
-func TestInsideBbox(t *testing.T) {
-	t.Log("ruler inside bbox is correct")
+func TestAngularDistance(t *testing.T) {
+	t.Log("ruler angular distance is correct")
 
 	ruler, _ := NewRuler(48.8629, "meters")
-	bbox := Bbox{2.349946, 48.862990, 2.350162, 48.863318}
-	pointIn := Point{2.35, 48.863}
-	pointOut := Point{2.349, 48.863}
 
-	if !(ruler.InsideBbox(pointIn, bbox)) || ruler.InsideBbox(pointOut, bbox) {
-		t.Fatalf("Inside bbox gives false result")
+	cases := []struct {
+		a        Point
+		b        Point
+		expected float64
+	}{
+		{Point{0, 0}, Point{90, 0}, 90},
+		{Point{0, 0}, Point{0, 45}, 45},
+		{Point{0, 0}, Point{180, 0}, 180},
+		{Point{10, 90}, Point{-70, -90}, 180},
+		{Point{2.35, 48.86}, Point{2.35, 48.86}, 0},
 	}
 
-	t.Log("OK", bbox)
+	for _, c := range cases {
+		if d := ruler.AngularDistance(c.a, c.b); math.Abs(d-c.expected) > 1e-9 {
+			t.Fatalf("%f != %f", d, c.expected)
+		}
+	}
+
+	kilometers, _ := NewRuler(48.8629, "kilometers")
+	a := Point{2.344808, 48.862851}
+	b := Point{-74.0060, 40.7128}
+	if d, expected := ruler.AngularDistance(a, b), kilometers.AngularDistance(a, b); d != expected {
+		t.Fatalf("%f != %f", d, expected)
+	}
+	if d, expected := ruler.AngularDistance(a, b)*math.Pi/180*earthRadius*1000, ruler.HaversineDistance(a, b); math.Abs(d-expected) > 1e-6 {
+		t.Fatalf("%f != %f", d, expected)
+	}
+
+	t.Log("OK")
+}
+
+func TestHaversineDestination(t *testing.T) {
+	t.Log("ruler haversine destination is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	p := Point{2.344808, 48.862851}
+
+	near := ruler.HaversineDestination(p, 1000, 60)
+	if d := ruler.Distance(near, ruler.Destination(p, 1000, 60)); d > 5 {
+		t.Fatalf("%f should be close to 0", d)
+	}
+	if d := ruler.HaversineDistance(p, near); math.Abs(d-1000) > 1e-6 {
+		t.Fatalf("%f != %f", d, 1000.)
+	}
+
+	far := ruler.HaversineDestination(p, 3000000, 60)
+	if d := ruler.HaversineDistance(p, far); math.Abs(d-3000000) > 1e-3 {
+		t.Fatalf("%f != %f", d, 3000000.)
+	}
+	if d := ruler.HaversineDistance(far, ruler.Destination(p, 3000000, 60)); d < 30000 {
+		t.Fatalf("%f should diverge from 0", d)
+	}
+
+	t.Log("OK", far)
+}
+
+func TestDistance3(t *testing.T) {
+	t.Log("ruler 3D distance is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point3{2.344808, 48.862851, 35}
+	b := Point3{2.344808, 48.862851, 335}
+	c := Point3{2.352790, 48.862907, 35}
+
+	if d := ruler.Distance3(a, b); math.Abs(d-300) > 1e-9 {
+		t.Fatalf("%f != %f", d, 300.)
+	}
+
+	expected := ruler.Distance(Point{a[0], a[1]}, Point{c[0], c[1]})
+	if d := ruler.Distance3(a, c); math.Abs(d-expected) > 1e-9 {
+		t.Fatalf("%f != %f", d, expected)
+	}
+
+	if d := ruler.LineDistance3([]Point3{a, b, a, c}); math.Abs(d-(600+expected)) > 1e-9 {
+		t.Fatalf("%f != %f", d, 600+expected)
+	}
+
+	t.Log("OK")
+}
+
+func TestSlope(t *testing.T) {
+	t.Log("ruler slope is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.344808, 48.862851}
+	b := ruler.Offset(a, 200, 0)
+
+	if s := ruler.Slope(Point3{a[0], a[1], 10}, Point3{b[0], b[1], 25}); math.Abs(s-7.5) > 1e-6 {
+		t.Fatalf("%f != %f", s, 7.5)
+	}
+
+	if s := ruler.Slope(Point3{b[0], b[1], 25}, Point3{a[0], a[1], 10}); math.Abs(s+7.5) > 1e-6 {
+		t.Fatalf("%f != %f", s, -7.5)
+	}
+
+	if s := ruler.Slope(Point3{a[0], a[1], 10}, Point3{a[0], a[1], 25}); s != 0 {
+		t.Fatalf("%f != %f", s, 0.)
+	}
+
+	t.Log("OK")
+}
+
+func TestLineDistance(t *testing.T) {
+	t.Log("ruler line distance is correct")
+
+	ruler, _ := NewRuler(48.8629, "miles")
+	distance := ruler.LineDistance(testLine)
+	expected := 0.220571
+
+	if math.Abs(distance-expected) > 1e-2 {
+		t.Fatalf("%f != %f", distance, expected)
+	}
+
+	t.Log("OK", distance)
+}
+
+func TestAntimeridian(t *testing.T) {
+	t.Log("ruler distances across the antimeridian are correct")
+
+	ruler, _ := NewRuler(60, "kilometers")
+	a := Point{179, 60}
+	b := Point{-179, 60}
+	expected := ruler.Distance(Point{-1, 60}, Point{1, 60})
+
+	if d := ruler.Distance(a, b); math.Abs(d-expected) > 1e-9 {
+		t.Fatalf("%f != %f", d, expected)
+	}
+	if d := ruler.Distance(b, a); math.Abs(d-expected) > 1e-9 {
+		t.Fatalf("%f != %f", d, expected)
+	}
+
+	line := Line{{178, 60}, a, b, {-178, 60}}
+	if d := ruler.LineDistance(line); math.Abs(d-2*expected) > 1e-9 {
+		t.Fatalf("%f != %f", d, 2*expected)
+	}
+
+	equator, _ := NewRuler(0, "kilometers")
+	crossing := Line{{179, 0}, {-179, 0}}
+	length := equator.LineDistance(crossing)
+	if length > 300 {
+		t.Fatalf("%f should be a short distance", length)
+	}
+
+	distances, _ := equator.Distances([]Point{crossing[0]}, []Point{crossing[1]})
+	if math.Abs(distances[0]-length) > 1e-9 {
+		t.Fatalf("%f != %f", distances[0], length)
+	}
+
+	for _, p := range []Point{equator.Along(crossing, length/2), NewLineIndex(equator, crossing).At(length / 2)} {
+		if math.Abs(math.Abs(p[0])-180) > 1e-9 || p[1] != 0 {
+			t.Fatalf("%+v should be on the antimeridian", p)
+		}
+	}
+	if p := equator.Along(crossing, length*3/4); math.Abs(p[0]+179.5) > 1e-9 {
+		t.Fatalf("%f != %f", p[0], -179.5)
+	}
+
+	if bearing := equator.Bearing(crossing[0], crossing[1]); math.Abs(bearing-90) > 1e-9 {
+		t.Fatalf("%f != %f", bearing, 90.)
+	}
+
+	p := Point{180, 0.001}
+	if d, expected := equator.DistanceToLine(crossing, p), equator.Distance(Point{180, 0}, p); math.Abs(d-expected) > 1e-9 {
+		t.Fatalf("%f != %f", d, expected)
+	}
+
+	// methods that work on raw longitudes keep lines using longitudes beyond 180 degrees unchanged
+	raw := Line{{170, 0}, {190, 0}}
+	if pol := equator.PointOnLine(raw, Point{185, 0.001}); math.Abs(pol.Coordinate()[0]-185) > 1e-9 {
+		t.Fatalf("%f != %f", pol.Coordinate()[0], 185.)
+	}
+	if p := equator.Along(raw, equator.LineDistance(raw)*3/4); math.Abs(p[0]-185) > 1e-9 {
+		t.Fatalf("%f != %f", p[0], 185.)
+	}
+
+	clipCases := []struct {
+		line     Line
+		bbox     Bbox
+		expected Line
+	}{
+		{Line{{170, 0}, {-170, 0}}, Bbox{-10, -1, 10, 1}, Line{{10, 0}, {-10, 0}}},
+		{raw, Bbox{175, -1, 185, 1}, Line{{175, 0}, {185, 0}}},
+	}
+	for _, c := range clipCases {
+		parts := equator.ClipToBbox(c.line, c.bbox)
+		if len(parts) != 1 || len(parts[0]) != 2 ||
+			math.Abs(parts[0][0][0]-c.expected[0][0]) > 1e-9 || math.Abs(parts[0][1][0]-c.expected[1][0]) > 1e-9 {
+			t.Fatalf("%+v != %+v", parts, c.expected)
+		}
+	}
+
+	rectangle := func(minX float64, maxX float64) Polygon {
+		return Polygon{Line{{minX, -1}, {maxX, -1}, {maxX, 1}, {minX, 1}, {minX, -1}}}
+	}
+	if area, expected := equator.IntersectionArea(rectangle(170, 185), rectangle(175, 190)), equator.Area(rectangle(175, 185)); math.Abs(area-expected) > 1e-6 {
+		t.Fatalf("%f != %f", area, expected)
+	}
+
+	cases := [][2]float64{
+		{0, 0},
+		{180, 180},
+		{-180, -180},
+		{190, -170},
+		{-190, 170},
+		{540, 180},
+		{725, 5},
+	}
+	for _, c := range cases {
+		if lon := NormalizeLongitude(c[0]); math.Abs(lon-c[1]) > 1e-9 {
+			t.Fatalf("%f != %f", lon, c[1])
+		}
+	}
+
+	t.Log("OK", expected)
+}
+
+func TestPerimeter(t *testing.T) {
+	t.Log("ruler perimeter is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	b := ruler.Offset(a, 100, 0)
+	c := ruler.Offset(a, 100, 100)
+	d := ruler.Offset(a, 0, 100)
+
+	closed := ruler.Perimeter(Polygon{Line{a, b, c, d, a}})
+	open := ruler.Perimeter(Polygon{Line{a, b, c, d}})
+	expected := 400.
+
+	if math.Abs(closed-expected) > 1e-6 || math.Abs(open-expected) > 1e-6 {
+		t.Fatalf("%f, %f != %f", closed, open, expected)
+	}
+
+	t.Log("OK", closed)
+}
+
+func TestOuterRingLength(t *testing.T) {
+	t.Log("ruler outer ring length is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	outer := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100), ruler.Offset(a, 0, 100), a}
+	h := ruler.Offset(a, 25, 25)
+	hole := Line{h, ruler.Offset(h, 0, 50), ruler.Offset(h, 50, 50), ruler.Offset(h, 50, 0), h}
+
+	if length := ruler.OuterRingLength(Polygon{outer, hole}); math.Abs(length-400) > 1e-6 {
+		t.Fatalf("%f != %f", length, 400.)
+	}
+	if length := ruler.OuterRingLength(Polygon{outer[:4]}); math.Abs(length-400) > 1e-6 {
+		t.Fatalf("%f != %f", length, 400.)
+	}
+	if length := ruler.OuterRingLength(Polygon{}); length != 0 {
+		t.Fatalf("%f != 0", length)
+	}
+
+	t.Log("OK")
+}
+
+func TestCumulativeDistances(t *testing.T) {
+	t.Log("ruler cumulative distances are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	distances := ruler.CumulativeDistances(testLine)
+
+	if len(distances) != len(testLine) || distances[0] != 0 {
+		t.Fatalf("%+v should have %d distances starting with 0", distances, len(testLine))
+	}
+
+	if expected := ruler.LineDistance(testLine); math.Abs(distances[len(distances)-1]-expected) > 1e-9 {
+		t.Fatalf("%f != %f", distances[len(distances)-1], expected)
+	}
+
+	if expected := ruler.LineDistance(testLine[:3]); math.Abs(distances[2]-expected) > 1e-9 {
+		t.Fatalf("%f != %f", distances[2], expected)
+	}
+
+	t.Log("OK", distances)
+}
+
+func TestLineDistanceStream(t *testing.T) {
+	t.Log("ruler streamed line distance is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	pts := make(chan Point)
+
+	go func() {
+		for _, p := range testLine {
+			pts <- p
+		}
+		close(pts)
+	}()
+
+	distance := ruler.LineDistanceStream(pts)
+	expected := ruler.LineDistance(testLine)
+
+	if math.Abs(distance-expected) > 1e-9 {
+		t.Fatalf("%f != %f", distance, expected)
+	}
+
+	t.Log("OK", distance)
+}
+
+func TestBearing(t *testing.T) {
+	t.Log("ruler bearing is correct")
+
+	ruler, _ := NewRuler(48.8629, "miles")
+	a := [2]float64{2.344808, 48.862851}
+	b := [2]float64{2.352790, 48.862907}
+	bearing := ruler.Bearing(a, b)
+	expected := 89.39
+
+	if math.Abs(bearing-expected) > 1e-2 {
+		t.Fatalf("%f != %f", bearing, expected)
+	}
+
+	t.Log("OK", bearing)
+}
+
+func TestBearings(t *testing.T) {
+	t.Log("ruler bearings are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	bearings := ruler.Bearings(testLine)
+
+	if len(bearings) != len(testLine)-1 {
+		t.Fatalf("%d != %d", len(bearings), len(testLine)-1)
+	}
+
+	for _, i := range []int{0, 3} {
+		if expected := ruler.Bearing(testLine[i], testLine[i+1]); bearings[i] != expected {
+			t.Fatalf("%f != %f", bearings[i], expected)
+		}
+	}
+
+	if empty := ruler.Bearings(testLine[:1]); len(empty) != 0 {
+		t.Fatalf("%+v should be empty", empty)
+	}
+
+	t.Log("OK", bearings)
+}
+
+func TestSegments(t *testing.T) {
+	t.Log("ruler segments are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	segments := ruler.Segments(testLine)
+
+	if len(segments) != len(testLine)-1 {
+		t.Fatalf("%d != %d", len(segments), len(testLine)-1)
+	}
+
+	var distance float64
+	for i, s := range segments {
+		if s[0] != testLine[i] || s[1] != testLine[i+1] {
+			t.Fatalf("%+v != %+v", s, [2]Point{testLine[i], testLine[i+1]})
+		}
+		distance += ruler.Distance(s[0], s[1])
+	}
+
+	if expected := ruler.LineDistance(testLine); math.Abs(distance-expected) > 1e-6 {
+		t.Fatalf("%f != %f", distance, expected)
+	}
+
+	if empty := ruler.Segments(testLine[:1]); len(empty) != 0 {
+		t.Fatalf("%+v should be empty", empty)
+	}
+
+	t.Log("OK", len(segments))
+}
+
+func TestBearingAtPointOnLine(t *testing.T) {
+	t.Log("ruler bearing at point on line is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+
+	for _, i := range []int{0, 3, len(testLine) - 2} {
+		p := ruler.Offset(interpolate(testLine[i], testLine[i+1], 0.5), 1, 1)
+		pol := ruler.PointOnLine(testLine, p)
+		if pol.Index() != i {
+			t.Fatalf("%d != %d", pol.Index(), i)
+		}
+		if bearing, expected := ruler.BearingAtPointOnLine(testLine, pol), ruler.Bearing(testLine[i], testLine[i+1]); bearing != expected {
+			t.Fatalf("%f != %f", bearing, expected)
+		}
+	}
+
+	if bearing := ruler.BearingAtPointOnLine(Line{}, ruler.PointOnLine(Line{}, testLine[0])); bearing != 0 {
+		t.Fatalf("%f != 0", bearing)
+	}
+	if bearing := ruler.BearingAtPointOnLine(testLine[:1], ruler.PointOnLine(testLine[:1], testLine[0])); bearing != 0 {
+		t.Fatalf("%f != 0", bearing)
+	}
+
+	t.Log("OK")
+}
+
+func TestCompassBearing(t *testing.T) {
+	t.Log("ruler compass bearing is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	cases := []struct {
+		b        Point
+		expected float64
+	}{
+		{Point{2.350, 48.863}, 0},
+		{Point{2.351, 48.862}, 90},
+		{Point{2.350, 48.861}, 180},
+		{Point{2.349, 48.862}, 270},
+	}
+
+	for _, c := range cases {
+		if bearing := ruler.CompassBearing(a, c.b); math.Abs(bearing-c.expected) > 1e-9 {
+			t.Fatalf("%f != %f", bearing, c.expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestRhumb(t *testing.T) {
+	t.Log("ruler rhumb distance and bearing are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	cases := []struct {
+		a, b     Point
+		distance float64
+		bearing  float64
+	}{
+		// values computed with turf.js rhumbDistance and rhumbBearing
+		{Point{2.344808, 48.862851}, Point{2.352790, 48.862907}, 583.925807, 89.388992},
+		{Point{2.3503875, 48.863598}, Point{2.3469865, 48.862147}, 296.524789, -122.964316},
+	}
+
+	for _, c := range cases {
+		distance := ruler.RhumbDistance(c.a, c.b)
+		bearing := ruler.RhumbBearing(c.a, c.b)
+
+		if math.Abs(distance-c.distance)/c.distance > 5e-3 {
+			t.Fatalf("%f != %f", distance, c.distance)
+		}
+
+		if math.Abs(bearing-c.bearing) > 1e-1 {
+			t.Fatalf("%f != %f", bearing, c.bearing)
+		}
+
+		destination := ruler.Destination(c.a, distance, bearing)
+		if math.Abs(destination[0]-c.b[0]) > 1e-9 || math.Abs(destination[1]-c.b[1]) > 1e-9 {
+			t.Fatalf("%+v != %+v", destination, c.b)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestOffset(t *testing.T) {
+	t.Log("ruler offset is correct")
+
+	ruler, _ := NewRuler(48.8629, "miles")
+	a := [2]float64{2.344808, 48.862851}
+	offset := ruler.Offset(a, 1., -2.)
+	expected := [2]float64{2.366741, 48.833907}
+
+	if math.Abs(offset[0]-expected[0]) > 1e-5 || math.Abs(offset[1]-expected[1]) > 1e-5 {
+		t.Fatalf("%+v != %+v", offset, expected)
+	}
+
+	t.Log("OK", offset)
+}
+
+func TestGrid(t *testing.T) {
+	t.Log("ruler grid is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	center := Point{2.344808, 48.862851}
+	grid := ruler.Grid(center, 5, 3, 10)
+
+	if len(grid) != 3 || len(grid[0]) != 5 {
+		t.Fatalf("%+v should have 3 rows of 5 points", grid)
+	}
+
+	expected := math.Hypot(20, 10)
+	for _, corner := range []Point{grid[0][0], grid[0][4], grid[2][0], grid[2][4]} {
+		if d := ruler.Distance(center, corner); math.Abs(d-expected) > 1e-6 {
+			t.Fatalf("%f != %f", d, expected)
+		}
+	}
+
+	if grid[0][0][0] >= grid[0][4][0] || grid[0][0][1] >= grid[2][0][1] {
+		t.Fatalf("%+v should go from south west to north east", grid)
+	}
+
+	if d := ruler.Distance(center, grid[1][2]); d > 1e-9 {
+		t.Fatalf("%f != %f", d, 0.)
+	}
+
+	t.Log("OK", grid)
+}
+
+func TestDestination(t *testing.T) {
+	t.Log("ruler destination is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := [2]float64{2.344808, 48.862851}
+	destination := ruler.Destination(a, 1., 30.)
+	expected := [2]float64{2.344814, 48.862858}
+
+	if math.Abs(destination[0]-expected[0]) > 1e-5 || math.Abs(destination[1]-expected[1]) > 1e-5 {
+		t.Fatalf("%+v != %+v", destination, expected)
+	}
+
+	t.Log("OK", destination)
+}
+
+func TestDestinationAndBack(t *testing.T) {
+	t.Log("ruler destination and back bearing are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.344808, 48.862851}
+	destination, back := ruler.DestinationAndBack(a, 100., 30.)
+	expected := ruler.Destination(a, 100., 30.)
+
+	if destination != expected {
+		t.Fatalf("%+v != %+v", destination, expected)
+	}
+
+	if math.Abs(back-(30.-180.)) > 1e-6 {
+		t.Fatalf("%f != %f", back, 30.-180.)
+	}
+
+	t.Log("OK", destination, back)
+}
+
+func TestMidpoint(t *testing.T) {
+	t.Log("ruler midpoint is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.344808, 48.862851}
+	b := Point{2.352790, 48.862907}
+	midpoint := ruler.Midpoint(a, b)
+	expected := ruler.Along(Line{a, b}, ruler.Distance(a, b)/2)
+
+	if math.Abs(midpoint[0]-expected[0]) > 1e-9 || math.Abs(midpoint[1]-expected[1]) > 1e-9 {
+		t.Fatalf("%+v != %+v", midpoint, expected)
+	}
+
+	t.Log("OK", midpoint)
+}
+
+func TestCentroid(t *testing.T) {
+	t.Log("ruler centroid is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	square := Polygon{Line{
+		Point{2.350, 48.862},
+		Point{2.352, 48.862},
+		Point{2.352, 48.864},
+		Point{2.350, 48.864},
+		Point{2.350, 48.862},
+	}}
+	lShape := Polygon{Line{
+		Point{2.350, 48.862},
+		Point{2.352, 48.862},
+		Point{2.352, 48.863},
+		Point{2.351, 48.863},
+		Point{2.351, 48.864},
+		Point{2.350, 48.864},
+		Point{2.350, 48.862},
+	}}
+	withHole := Polygon{square[0], Line{
+		Point{2.351, 48.863},
+		Point{2.352, 48.863},
+		Point{2.352, 48.864},
+		Point{2.351, 48.864},
+		Point{2.351, 48.863},
+	}}
+	degenerate := Polygon{Line{
+		Point{2.350, 48.862},
+		Point{2.352, 48.862},
+		Point{2.354, 48.862},
+		Point{2.350, 48.862},
+	}}
+
+	cases := []struct {
+		polygon  Polygon
+		expected Point
+	}{
+		{square, Point{2.351, 48.863}},
+		{lShape, Point{2.350 + 0.0025/3, 48.862 + 0.0025/3}},
+		{withHole, Point{2.350 + 0.0025/3, 48.862 + 0.0025/3}},
+		{degenerate, Point{2.352, 48.862}},
+	}
+
+	for _, c := range cases {
+		centroid := ruler.Centroid(c.polygon)
+		if math.Abs(centroid[0]-c.expected[0]) > 1e-9 || math.Abs(centroid[1]-c.expected[1]) > 1e-9 {
+			t.Fatalf("%+v != %+v", centroid, c.expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestMeanCenter(t *testing.T) {
+	t.Log("ruler mean center is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	center := Point{2.350, 48.862}
+	pts := []Point{
+		ruler.Offset(center, -100, -50),
+		ruler.Offset(center, 100, -50),
+		ruler.Offset(center, 100, 50),
+		ruler.Offset(center, -100, 50),
+		center,
+	}
+
+	if p := ruler.MeanCenter(pts); ruler.Distance(p, center) > 1e-6 {
+		t.Fatalf("%+v != %+v", p, center)
+	}
+
+	if p := ruler.MeanCenter(pts[:1]); ruler.Distance(p, pts[0]) > 1e-9 {
+		t.Fatalf("%+v != %+v", p, pts[0])
+	}
+
+	if p := ruler.MeanCenter([]Point{}); p != (Point{}) {
+		t.Fatalf("%+v != %+v", p, Point{})
+	}
+
+	t.Log("OK")
+}
+
+func TestMedianCenter(t *testing.T) {
+	t.Log("ruler median center is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	center := Point{2.350, 48.862}
+	pts := []Point{
+		ruler.Offset(center, -10, -10),
+		ruler.Offset(center, 10, -10),
+		ruler.Offset(center, 10, 10),
+		ruler.Offset(center, -10, 10),
+		ruler.Offset(center, 10000, 0),
+	}
+
+	mean := ruler.MeanCenter(pts)
+	median := ruler.MedianCenter(pts, 100)
+
+	if d := ruler.Distance(mean, center); d < 1000 {
+		t.Fatalf("%f should be pulled toward the outlier", d)
+	}
+	if d := ruler.Distance(median, center); d > 10 {
+		t.Fatalf("%f should be close to 0", d)
+	}
+
+	sum := func(p Point) float64 {
+		var total float64
+		for _, q := range pts {
+			total += ruler.Distance(p, q)
+		}
+		return total
+	}
+	if sum(median) > sum(mean) {
+		t.Fatalf("%f should be less than %f", sum(median), sum(mean))
+	}
+
+	if p := ruler.MedianCenter(pts, 0); p != mean {
+		t.Fatalf("%+v != %+v", p, mean)
+	}
+	if p := ruler.MedianCenter([]Point{}, 10); p != (Point{}) {
+		t.Fatalf("%+v != %+v", p, Point{})
+	}
+
+	t.Log("OK", ruler.Distance(median, center))
+}
+
+func TestPoleOfInaccessibility(t *testing.T) {
+	t.Log("ruler pole of inaccessibility is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	c := Polygon{Line{
+		a,
+		ruler.Offset(a, 300, 0),
+		ruler.Offset(a, 300, 100),
+		ruler.Offset(a, 100, 100),
+		ruler.Offset(a, 100, 200),
+		ruler.Offset(a, 300, 200),
+		ruler.Offset(a, 300, 300),
+		ruler.Offset(a, 0, 300),
+		a,
+	}}
+
+	if ruler.PointInPolygon(ruler.Centroid(c), c) {
+		t.Fatal("the centroid of the C-shaped polygon should be outside")
+	}
+
+	pole := ruler.PoleOfInaccessibility(c, 1)
+	if !ruler.PointInPolygon(pole, c) {
+		t.Fatalf("%+v should be inside the polygon", pole)
+	}
+	if d := ruler.distanceToRings(pole, c); d < 49 {
+		t.Fatalf("%f should be at least %f", d, 49.)
+	}
+
+	square := ruler.BboxToPolygon(Bbox{a[0], a[1], ruler.Offset(a, 200, 200)[0], ruler.Offset(a, 200, 200)[1]})
+	pole = ruler.PoleOfInaccessibility(square, 0.1)
+	if d := ruler.Distance(pole, ruler.Offset(a, 100, 100)); d > 0.1 {
+		t.Fatalf("%f should be close to 0", d)
+	}
+
+	if p := ruler.PoleOfInaccessibility(Polygon{}, 1); p != (Point{}) {
+		t.Fatalf("%+v != %+v", p, Point{})
+	}
+
+	t.Log("OK", pole)
+}
+
+func TestPointInPolygon(t *testing.T) {
+	t.Log("ruler point in polygon is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	polygon := Polygon{
+		Line{
+			Point{2.350, 48.862},
+			Point{2.354, 48.862},
+			Point{2.354, 48.866},
+			Point{2.350, 48.866},
+			Point{2.350, 48.862},
+		},
+		Line{
+			Point{2.351, 48.863},
+			Point{2.352, 48.863},
+			Point{2.352, 48.864},
+			Point{2.351, 48.864},
+			Point{2.351, 48.863},
+		},
+	}
+
+	cases := []struct {
+		point    Point
+		expected bool
+	}{
+		{Point{2.353, 48.865}, true},
+		{Point{2.355, 48.865}, false},
+		{Point{2.3515, 48.8635}, false},
+		{Point{2.350, 48.864}, true},
+		{Point{2.354, 48.866}, true},
+		{Point{2.351, 48.8635}, true},
+	}
+
+	for _, c := range cases {
+		if ruler.PointInPolygon(c.point, polygon) != c.expected {
+			t.Fatalf("%+v should be inside: %t", c.point, c.expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestAlong(t *testing.T) {
+	t.Log("ruler along is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	along := ruler.Along(testLine, 150.)
+	expected := [2]float64{2.349404, 48.862739}
+
+	if math.Abs(along[0]-expected[0]) > 1e-5 || math.Abs(along[1]-expected[1]) > 1e-5 {
+		t.Fatalf("%+v != %+v", along, expected)
+	}
+
+	t.Log("OK", along)
+}
+
+func TestAlongFraction(t *testing.T) {
+	t.Log("ruler along fraction is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	along := ruler.AlongFraction(testLine, 0.5)
+	expected := ruler.Along(testLine, ruler.LineDistance(testLine)/2)
+
+	if along != expected {
+		t.Fatalf("%+v != %+v", along, expected)
+	}
+
+	if start := ruler.AlongFraction(testLine, -1); start != testLine[0] {
+		t.Fatalf("%+v != %+v", start, testLine[0])
+	}
+
+	if end := ruler.AlongFraction(testLine, 2); end != testLine[len(testLine)-1] {
+		t.Fatalf("%+v != %+v", end, testLine[len(testLine)-1])
+	}
+
+	t.Log("OK", along)
+}
+
+func TestAdvanceAlong(t *testing.T) {
+	t.Log("ruler advance along is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+
+	cases := []struct {
+		from     Point
+		dist     float64
+		expected Point
+	}{
+		{testLine[0], 100, ruler.Along(testLine, 100)},
+		{ruler.Along(testLine, 150), 50, ruler.Along(testLine, 200)},
+		{ruler.Along(testLine, 150), -100, ruler.Along(testLine, 50)},
+		{ruler.Along(testLine, 150), -1000, testLine[0]},
+		{testLine[0], 1e6, testLine[len(testLine)-1]},
+	}
+
+	for _, c := range cases {
+		if p := ruler.AdvanceAlong(testLine, c.from, c.dist); ruler.Distance(p, c.expected) > 1e-6 {
+			t.Fatalf("%+v != %+v", p, c.expected)
+		}
+	}
+
+	if p := ruler.AdvanceAlong(Line{}, testLine[0], 10); p != (Point{}) {
+		t.Fatalf("%+v != %+v", p, Point{})
+	}
+
+	t.Log("OK")
+}
+
+func TestPointOnLine(t *testing.T) {
+	t.Log("ruler pointOnLine is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	pol := ruler.PointOnLine(testLine, [2]float64{2.350, 48.861})
+	var expected PointOnLine = PointOnLine{
+		point: [2]float64{2.3500358, 48.862734},
+		index: 1,
+		t:     0.048116,
+	}
+
+	if math.Abs(pol.Coordinate()[0]-expected.point[0]) > 1e-5 ||
+		math.Abs(pol.Coordinate()[1]-expected.point[1]) > 1e-5 ||
+		pol.Index() != expected.index ||
+		math.Abs(pol.T()-expected.t) > 1e-5 {
+		t.Fatalf("%+v != %+v", pol, expected)
+	}
+
+	t.Log("OK", pol)
+}
+
+func TestSnapWithEndpointFlag(t *testing.T) {
+	t.Log("ruler snap with endpoint flag is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	start := ruler.Destination(testLine[0], 5, ruler.Bearing(testLine[1], testLine[0]))
+	end := testLine[len(testLine)-1]
+	beyond := ruler.Destination(end, 5, ruler.Bearing(testLine[len(testLine)-2], end))
+
+	cases := []struct {
+		line     Line
+		point    Point
+		expected bool
+	}{
+		{testLine, start, true},
+		{testLine, testLine[0], true},
+		{testLine, beyond, true},
+		{testLine, end, true},
+		{testLine, ruler.Along(testLine, 100), false},
+		{testLine, testLine[2], false},
+		{testLine[:1], start, true},
+		{Line{}, start, false},
+	}
+
+	for _, c := range cases {
+		pol, endpoint := ruler.SnapWithEndpointFlag(c.line, c.point)
+		if endpoint != c.expected {
+			t.Fatalf("%+v: %t != %t", c.point, endpoint, c.expected)
+		}
+		if expected := ruler.PointOnLine(c.line, c.point); pol != expected {
+			t.Fatalf("%+v != %+v", pol, expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestPerpendicularFoot(t *testing.T) {
+	t.Log("ruler perpendicular foot is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	b := ruler.Offset(a, 100, 0)
+
+	cases := []struct {
+		point    Point
+		expected Point
+		t        float64
+	}{
+		{ruler.Offset(a, 150, 20), ruler.Offset(a, 150, 0), 1.5},
+		{ruler.Offset(a, -50, -20), ruler.Offset(a, -50, 0), -0.5},
+		{ruler.Offset(a, 25, 40), ruler.Offset(a, 25, 0), 0.25},
+	}
+
+	for _, c := range cases {
+		foot, tt := ruler.PerpendicularFoot(a, b, c.point)
+		if ruler.Distance(foot, c.expected) > 1e-6 || math.Abs(tt-c.t) > 1e-9 {
+			t.Fatalf("%+v, %f != %+v, %f", foot, tt, c.expected, c.t)
+		}
+	}
+
+	if foot, tt := ruler.PerpendicularFoot(a, a, b); foot != a || tt != 0 {
+		t.Fatalf("%+v, %f != %+v, 0", foot, tt, a)
+	}
+
+	t.Log("OK")
+}
+
+func TestSideOfLine(t *testing.T) {
+	t.Log("ruler side of line is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	b := Point{2.351, 48.862}
+
+	cases := []struct {
+		point    Point
+		expected int
+	}{
+		{ruler.Offset(a, 50, 20), 1},
+		{ruler.Offset(a, 50, -20), -1},
+		{Point{2.3505, 48.862}, 0},
+		{Point{2.352, 48.862}, 0},
+		{a, 0},
+	}
+
+	for _, c := range cases {
+		if side := ruler.SideOfLine(a, b, c.point); side != c.expected {
+			t.Fatalf("%d != %d", side, c.expected)
+		}
+		if side := ruler.SideOfLine(b, a, c.point); side != -c.expected {
+			t.Fatalf("%d != %d", side, -c.expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestLinearReference(t *testing.T) {
+	t.Log("ruler linear reference is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	line := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 200, 0)}
+
+	cases := []struct {
+		point  Point
+		along  float64
+		offset float64
+	}{
+		{ruler.Offset(a, 150, -20), 150, -20},
+		{ruler.Offset(a, 30, 40), 30, 40},
+		{ruler.Offset(a, 100, 0), 100, 0},
+		{ruler.Offset(a, 250, 0), 250, 0},
+		{ruler.Offset(a, 250, 10), 250, 10},
+		{ruler.Offset(a, -30, -10), -30, -10},
+	}
+
+	for _, c := range cases {
+		along, offset := ruler.LinearReference(line, c.point)
+		if math.Abs(along-c.along) > 1e-6 || math.Abs(offset-c.offset) > 1e-6 {
+			t.Fatalf("%f, %f != %f, %f", along, offset, c.along, c.offset)
+		}
+	}
+
+	if along, offset := ruler.LinearReference(line[:1], a); along != 0 || offset != 0 {
+		t.Fatalf("%f, %f != 0, 0", along, offset)
+	}
+
+	t.Log("OK")
+}
+
+func TestFromLinearReference(t *testing.T) {
+	t.Log("ruler from linear reference is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	line := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100)}
+
+	if p, expected := ruler.FromLinearReference(line, 50, -20), ruler.Offset(a, 50, -20); ruler.Distance(p, expected) > 1e-6 {
+		t.Fatalf("%+v != %+v", p, expected)
+	}
+	if p, expected := ruler.FromLinearReference(line, 150, -20), ruler.Offset(a, 120, 50); ruler.Distance(p, expected) > 1e-6 {
+		t.Fatalf("%+v != %+v", p, expected)
+	}
+	if p, expected := ruler.FromLinearReference(line, 250, 0), ruler.Offset(a, 100, 150); ruler.Distance(p, expected) > 1e-6 {
+		t.Fatalf("%+v != %+v", p, expected)
+	}
+
+	cases := [][2]float64{{10, -5}, {50, 30}, {80, -15}, {130, 20}, {150, -10}, {190, 5}, {250, 0}, {230, 12}, {-40, -7}}
+	for _, c := range cases {
+		along, offset := ruler.LinearReference(line, ruler.FromLinearReference(line, c[0], c[1]))
+		if math.Abs(along-c[0]) > 1e-6 || math.Abs(offset-c[1]) > 1e-6 {
+			t.Fatalf("%f, %f != %f, %f", along, offset, c[0], c[1])
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestDistanceToLine(t *testing.T) {
+	t.Log("ruler distance to line is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	p := Point{2.350, 48.861}
+	distance := ruler.DistanceToLine(testLine, p)
+	expected := ruler.Distance(p, ruler.PointOnLine(testLine, p).Coordinate())
+
+	if math.Abs(distance-expected) > 1e-9 {
+		t.Fatalf("%f != %f", distance, expected)
+	}
+
+	if d := ruler.DistanceToLine(Line{}, p); !math.IsInf(d, 1) {
+		t.Fatalf("%f != +Inf", d)
+	}
+
+	t.Log("OK", distance)
+}
+
+func TestRemainingDistance(t *testing.T) {
+	t.Log("ruler remaining distance is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	length := ruler.LineDistance(testLine)
+
+	near := ruler.Destination(testLine[0], 5, ruler.Bearing(testLine[1], testLine[0]))
+	if d := ruler.RemainingDistance(testLine, near); math.Abs(d-length) > 1e-6 {
+		t.Fatalf("%f != %f", d, length)
+	}
+
+	end := testLine[len(testLine)-1]
+	beyond := ruler.Destination(end, 5, ruler.Bearing(testLine[len(testLine)-2], end))
+	if d := ruler.RemainingDistance(testLine, beyond); d > 1e-6 {
+		t.Fatalf("%f should be close to 0", d)
+	}
+
+	middle := ruler.Along(testLine, length/3)
+	if d := ruler.RemainingDistance(testLine, middle); math.Abs(d-length*2/3) > 1e-6 {
+		t.Fatalf("%f != %f", d, length*2/3)
+	}
+
+	if d := ruler.RemainingDistance(testLine[:1], near); d != 0 {
+		t.Fatalf("%f != 0", d)
+	}
+
+	t.Log("OK", length)
+}
+
+func TestDistanceToPolygon(t *testing.T) {
+	t.Log("ruler distance to polygon is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	polygon := Polygon{
+		Line{a, ruler.Offset(a, 300, 0), ruler.Offset(a, 300, 300), ruler.Offset(a, 0, 300), a},
+		Line{ruler.Offset(a, 100, 100), ruler.Offset(a, 100, 200), ruler.Offset(a, 200, 200), ruler.Offset(a, 200, 100)},
+	}
+
+	cases := []struct {
+		point    Point
+		expected float64
+	}{
+		{ruler.Offset(a, 50, 150), 0},
+		{ruler.Offset(a, -40, 150), 40},
+		{ruler.Offset(a, 150, 120), 20},
+		{ruler.Offset(a, 180, 150), 20},
+	}
+
+	for _, c := range cases {
+		if d := ruler.DistanceToPolygon(c.point, polygon); math.Abs(d-c.expected) > 1e-6 {
+			t.Fatalf("%f != %f", d, c.expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestPointOnLineDegenerate(t *testing.T) {
+	t.Log("ruler pointOnLine handles empty and single-point lines")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	p := Point{2.350, 48.861}
+
+	pol := ruler.PointOnLine(Line{}, p)
+	if pol.Index() != -1 {
+		t.Fatalf("%+v should have an index of -1", pol)
+	}
+
+	pol = ruler.PointOnLine(Line{testLine[0]}, p)
+	if pol.Coordinate() != testLine[0] || pol.Index() != 0 || pol.T() != 0 {
+		t.Fatalf("%+v should snap to %+v", pol, testLine[0])
+	}
+
+	if along := ruler.Along(Line{}, 10); along != (Point{}) {
+		t.Fatalf("%+v != %+v", along, Point{})
+	}
+
+	if along := ruler.Along(Line{testLine[0]}, 10); along != testLine[0] {
+		t.Fatalf("%+v != %+v", along, testLine[0])
+	}
+
+	if slice := ruler.LineSlice(p, p, Line{}); len(slice) != 0 {
+		t.Fatalf("%+v should be empty", slice)
+	}
+
+	if slice := ruler.LineSlice(p, p, Line{testLine[0]}); len(slice) != 1 || slice[0] != testLine[0] {
+		t.Fatalf("%+v != %+v", slice, Line{testLine[0]})
+	}
+
+	t.Log("OK", pol)
+}
+
+func TestPointsOnLine(t *testing.T) {
+	t.Log("ruler pointsOnLine is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	probes := make([]Point, 1000)
+	for i := range probes {
+		probes[i] = Point{2.346 + float64(i)*0.000005, 48.861 + float64(i%7)*0.0005}
+	}
+
+	results := ruler.PointsOnLine(testLine, probes)
+
+	if len(results) != len(probes) {
+		t.Fatalf("%d != %d", len(results), len(probes))
+	}
+
+	for i, p := range probes {
+		if expected := ruler.PointOnLine(testLine, p); results[i] != expected {
+			t.Fatalf("%+v != %+v", results[i], expected)
+		}
+	}
+
+	if empty := ruler.PointsOnLine(testLine, nil); len(empty) != 0 {
+		t.Fatalf("%+v should be empty", empty)
+	}
+
+	t.Log("OK", len(results))
+}
+
+func BenchmarkPointsOnLine(b *testing.B) {
+	ruler, _ := NewRuler(48.8629, "meters")
+	line := ruler.Densify(testLine, 1)
+	probes := make([]Point, 10000)
+	for i := range probes {
+		probes[i] = Point{2.346 + float64(i)*0.0000005, 48.861 + float64(i%7)*0.0005}
+	}
+
+	for i := 0; i < b.N; i++ {
+		ruler.PointsOnLine(line, probes)
+	}
+}
+
+func TestNearestOnLines(t *testing.T) {
+	t.Log("ruler nearest on lines is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	other := Line{Point{2.340, 48.850}, Point{2.345, 48.851}}
+	p := Point{2.350, 48.861}
+
+	index, pol := ruler.NearestOnLines([]Line{other, testLine}, p)
+	expected := ruler.PointOnLine(testLine, p)
+
+	if index != 1 || pol != expected {
+		t.Fatalf("%d, %+v != %d, %+v", index, pol, 1, expected)
+	}
+
+	index, _ = ruler.NearestOnLines([]Line{testLine, testLine}, p)
+	if index != 0 {
+		t.Fatalf("ties should return the lowest index, got %d", index)
+	}
+
+	index, pol = ruler.NearestOnLines(nil, p)
+	if index != -1 || pol != (PointOnLine{}) {
+		t.Fatalf("%d, %+v != -1, %+v", index, pol, PointOnLine{})
+	}
+
+	t.Log("OK", index, pol)
+}
+
+func TestNearestVertex(t *testing.T) {
+	t.Log("ruler nearest vertex is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	p := Point{2.3484, 48.8625}
+	index, distance := ruler.NearestVertex(testLine, p)
+	expected := ruler.Distance(testLine[3], p)
+
+	if index != 3 || distance != expected {
+		t.Fatalf("%d, %f != %d, %f", index, distance, 3, expected)
+	}
+
+	index, _ = ruler.NearestVertex(Line{testLine[1], testLine[0], testLine[1]}, testLine[1])
+	if index != 0 {
+		t.Fatalf("ties should return the lowest index, got %d", index)
+	}
+
+	index, distance = ruler.NearestVertex(Line{}, p)
+	if index != -1 || !math.IsInf(distance, 1) {
+		t.Fatalf("%d, %f != -1, +Inf", index, distance)
+	}
+
+	t.Log("OK", index, distance)
+}
+
+func TestNearestPoint(t *testing.T) {
+	t.Log("ruler nearest point is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	query := Point{2.350, 48.862}
+	pts := []Point{
+		ruler.Offset(query, 100, 0),
+		ruler.Offset(query, 0, 30),
+		ruler.Offset(query, -50, 0),
+		ruler.Offset(query, 0, -30),
+	}
+
+	i, d := ruler.NearestPoint(pts, query)
+	if i != 1 || math.Abs(d-30) > 1e-6 {
+		t.Fatalf("%d, %f != %d, %f", i, d, 1, 30.)
+	}
+
+	i, d = ruler.NearestPoint([]Point{pts[0], pts[2], pts[0], pts[2]}, query)
+	if i != 1 || math.Abs(d-50) > 1e-6 {
+		t.Fatalf("%d, %f != %d, %f", i, d, 1, 50.)
+	}
+
+	i, d = ruler.NearestPoint([]Point{}, query)
+	if i != -1 || !math.IsInf(d, 1) {
+		t.Fatalf("%d, %f != -1, +Inf", i, d)
+	}
+
+	t.Log("OK")
+}
+
+func TestClosestPair(t *testing.T) {
+	t.Log("ruler closest pair is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	pts := []Point{
+		ruler.Offset(a, 0, 0),
+		ruler.Offset(a, 500, 300),
+		ruler.Offset(a, 200, -100),
+		ruler.Offset(a, -300, 400),
+		ruler.Offset(a, 503, 304),
+		ruler.Offset(a, 100, 100),
+	}
+
+	i, j, d := ruler.ClosestPair(pts)
+	if i != 1 || j != 4 || math.Abs(d-5) > 1e-6 {
+		t.Fatalf("%d, %d, %f != %d, %d, %f", i, j, d, 1, 4, 5.)
+	}
+
+	random := rand.New(rand.NewSource(42))
+	for n := 0; n < 20; n++ {
+		pts := make([]Point, 50)
+		for k := range pts {
+			pts[k] = ruler.Offset(a, random.Float64()*1000, random.Float64()*1000)
+		}
+
+		i, j, d := ruler.ClosestPair(pts)
+		expectedI, expectedJ, expected := -1, -1, math.Inf(1)
+		for k := range pts {
+			for l := k + 1; l < len(pts); l++ {
+				if dist := ruler.Distance(pts[k], pts[l]); dist < expected {
+					expectedI, expectedJ, expected = k, l, dist
+				}
+			}
+		}
+		if i != expectedI || j != expectedJ || math.Abs(d-expected) > 1e-9 {
+			t.Fatalf("%d, %d, %f != %d, %d, %f", i, j, d, expectedI, expectedJ, expected)
+		}
+	}
+
+	if i, j, d := ruler.ClosestPair([]Point{pts[2], pts[0], pts[2]}); i != 0 || j != 2 || d != 0 {
+		t.Fatalf("%d, %d, %f != 0, 2, 0", i, j, d)
+	}
+
+	if i, j, d := ruler.ClosestPair(pts[:1]); i != -1 || j != -1 || !math.IsInf(d, 1) {
+		t.Fatalf("%d, %d, %f != -1, -1, +Inf", i, j, d)
+	}
+
+	t.Log("OK")
+}
+
+func TestKNearest(t *testing.T) {
+	t.Log("ruler k nearest is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	query := Point{2.350, 48.862}
+	pts := []Point{
+		ruler.Offset(query, 100, 0),
+		ruler.Offset(query, 0, 30),
+		ruler.Offset(query, -50, 0),
+		ruler.Offset(query, 0, 30),
+		ruler.Offset(query, 10, 0),
+		ruler.Offset(query, 0, -70),
+	}
+
+	cases := []struct {
+		k        int
+		expected []int
+	}{
+		{1, []int{4}},
+		{3, []int{4, 1, 3}},
+		{5, []int{4, 1, 3, 2, 5}},
+		{10, []int{4, 1, 3, 2, 5, 0}},
+		{0, []int{}},
+	}
+
+	for _, c := range cases {
+		indexes := ruler.KNearest(pts, query, c.k)
+		if len(indexes) != len(c.expected) {
+			t.Fatalf("%+v != %+v", indexes, c.expected)
+		}
+		for i := range indexes {
+			if indexes[i] != c.expected[i] {
+				t.Fatalf("%+v != %+v", indexes, c.expected)
+			}
+		}
+	}
+
+	if indexes := ruler.KNearest([]Point{}, query, 3); len(indexes) != 0 {
+		t.Fatalf("%+v should be empty", indexes)
+	}
+
+	t.Log("OK")
+}
+
+func TestFarthestVertex(t *testing.T) {
+	t.Log("ruler farthest vertex is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	p := Point{2.3484, 48.8625}
+	index, distance := ruler.FarthestVertex(testLine, p)
+	expected := ruler.Distance(testLine[0], p)
+
+	if index != 0 || distance != expected {
+		t.Fatalf("%d, %f != %d, %f", index, distance, 0, expected)
+	}
+
+	index, distance = ruler.FarthestVertex(Line{}, p)
+	if index != -1 || distance != 0 {
+		t.Fatalf("%d, %f != -1, 0", index, distance)
+	}
+
+	t.Log("OK", index, distance)
+}
+
+func TestLineSlice(t *testing.T) {
+	t.Log("ruler line slice is correct")
+
+	ruler, _ := NewRuler(48.8629, "miles")
+	a := Point{2.350054, 48.863154}
+	b := Point{2.347555, 48.862145}
+	slice := ruler.LineSlice(a, b, testLine)
+	sliceDistance := ruler.LineDistance(slice)
+	expected := 0.164593
+
+	if math.Abs(sliceDistance-expected) > 1e-5 {
+		t.Fatalf("%f != %f", sliceDistance, expected)
+	}
+
+	t.Log("OK", slice)
+}
+
+func TestLineSliceSameSegment(t *testing.T) {
+	t.Log("ruler line slice is correct when both points snap on the same segment")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	start := ruler.PointOnLine(testLine, Point{2.3494, 48.8628})
+	end := ruler.PointOnLine(testLine, Point{2.3490, 48.8626})
+
+	if start.Index() != end.Index() || start.T() >= end.T() {
+		t.Fatalf("%+v and %+v should be ordered on the same segment", start, end)
+	}
+
+	for _, slice := range []Line{
+		ruler.LineSlice(Point{2.3494, 48.8628}, Point{2.3490, 48.8626}, testLine),
+		ruler.LineSlice(Point{2.3490, 48.8626}, Point{2.3494, 48.8628}, testLine),
+	} {
+		if len(slice) != 2 || slice[0] != start.Coordinate() || slice[1] != end.Coordinate() {
+			t.Fatalf("%+v != %+v", slice, Line{start.Coordinate(), end.Coordinate()})
+		}
+
+		expected := ruler.Distance(start.Coordinate(), end.Coordinate())
+		if math.Abs(ruler.LineDistance(slice)-expected) > 1e-9 {
+			t.Fatalf("%f != %f", ruler.LineDistance(slice), expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestLineSliceAlong(t *testing.T) {
+	t.Log("ruler line slice along is correct")
+
+	ruler, _ := NewRuler(48.8629, "miles")
+	slice := ruler.LineSliceAlong(0.05, 0.15, testLine)
+	sliceDistance := ruler.LineDistance(slice)
+	expected := 0.1
+
+	if math.Abs(sliceDistance-expected) > 1e-5 {
+		t.Fatalf("%f != %f", sliceDistance, expected)
+	}
+
+	t.Log("OK", slice)
+}
+
+func TestBufferPoint(t *testing.T) {
+	t.Log("ruler buffer point is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350054, 48.863154}
+	bbox := ruler.BufferPoint(a, 12)
+	expected := Bbox{2.349946, 48.862990, 2.350162, 48.863318}
+
+	if math.Abs(bbox[0]-expected[0]) > 1e-5 ||
+		math.Abs(bbox[1]-expected[1]) > 1e-5 ||
+		math.Abs(bbox[2]-expected[2]) > 1e-5 ||
+		math.Abs(bbox[3]-expected[3]) > 1e-5 {
+		t.Fatalf("%f != %f", bbox, expected)
+	}
+
+	t.Log("OK", bbox)
+}
+
+func TestBufferBbox(t *testing.T) {
+	t.Log("ruler buffer bbox is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Bbox{2.349946, 48.862990, 2.350162, 48.863318}
+	bbox := ruler.BufferBbox(a, 12)
+	expected := Bbox{2.349838, 48.862826, 2.350270, 48.863482}
+
+	if math.Abs(bbox[0]-expected[0]) > 1e-5 ||
+		math.Abs(bbox[1]-expected[1]) > 1e-5 ||
+		math.Abs(bbox[2]-expected[2]) > 1e-5 ||
+		math.Abs(bbox[3]-expected[3]) > 1e-5 {
+		t.Fatalf("%f != %f", bbox, expected)
+	}
+
+	t.Log("OK", bbox)
+}
+
+func TestCirclePolygon(t *testing.T) {
+	t.Log("ruler circle polygon is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	center := Point{2.350054, 48.863154}
+	circle := ruler.CirclePolygon(center, 12, 32)
+
+	if len(circle) != 1 || len(circle[0]) != 33 || circle[0][0] != circle[0][32] {
+		t.Fatalf("%+v is not a closed ring of 33 points", circle)
+	}
+
+	for _, p := range circle[0] {
+		if d := ruler.Distance(center, p); math.Abs(d-12) > 1e-6 {
+			t.Fatalf("%f != %f", d, 12.)
+		}
+	}
+
+	if triangle := ruler.CirclePolygon(center, 12, 1); len(triangle[0]) != 4 {
+		t.Fatalf("%+v should have 3 steps", triangle)
+	}
+
+	t.Log("OK", circle)
+}
+
+func TestBufferLine(t *testing.T) {
+	t.Log("ruler buffer line is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	b := ruler.Offset(a, 100, 0)
+	corridor := ruler.BufferLine(Line{a, b}, 10, 32)
+	midpoint := ruler.Midpoint(a, b)
+
+	if ring := corridor[0]; ring[0] != ring[len(ring)-1] {
+		t.Fatalf("%+v is not a closed ring", corridor)
+	}
+
+	for _, dy := range []float64{-9.99, 9.99} {
+		if p := ruler.Offset(midpoint, 0, dy); !ruler.PointInPolygon(p, corridor) {
+			t.Fatalf("%+v should be inside the corridor", p)
+		}
+	}
+
+	for _, dy := range []float64{-10.01, 10.01} {
+		if p := ruler.Offset(midpoint, 0, dy); ruler.PointInPolygon(p, corridor) {
+			t.Fatalf("%+v should be outside the corridor", p)
+		}
+	}
+
+	if p := ruler.Offset(b, 9.99, 0); !ruler.PointInPolygon(p, corridor) {
+		t.Fatalf("%+v should be inside the rounded end", p)
+	}
+
+	buffered := ruler.BufferLine(testLine, 10, 32)
+	for _, p := range buffered[0] {
+		pol := ruler.PointOnLine(testLine, p)
+		if d := ruler.Distance(pol.Coordinate(), p); math.Abs(d-10) > 1e-6 {
+			t.Fatalf("%+v is %f from the line", p, d)
+		}
+	}
+
+	t.Log("OK", corridor)
+}
+
+func TestInsideBbox(t *testing.T) {
+	t.Log("ruler inside bbox is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	bbox := Bbox{2.349946, 48.862990, 2.350162, 48.863318}
+	pointIn := Point{2.35, 48.863}
+	pointOut := Point{2.349, 48.863}
+
+	if !(ruler.InsideBbox(pointIn, bbox)) || ruler.InsideBbox(pointOut, bbox) {
+		t.Fatalf("Inside bbox gives false result")
+	}
+
+	t.Log("OK", bbox)
+}
+
+func TestClosestOnBbox(t *testing.T) {
+	t.Log("ruler closest on bbox is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	bbox := Bbox{2.34, 48.86, 2.35, 48.87}
+
+	cases := []struct {
+		point    Point
+		expected Point
+	}{
+		{Point{2.33, 48.865}, Point{2.34, 48.865}},
+		{Point{2.36, 48.865}, Point{2.35, 48.865}},
+		{Point{2.345, 48.85}, Point{2.345, 48.86}},
+		{Point{2.33, 48.88}, Point{2.34, 48.87}},
+		{Point{2.345, 48.865}, Point{2.345, 48.865}},
+	}
+
+	for _, c := range cases {
+		if p := ruler.ClosestOnBbox(c.point, bbox); p != c.expected {
+			t.Fatalf("%+v != %+v", p, c.expected)
+		}
+	}
+
+	left := ruler.Offset(Point{2.34, 48.865}, -100, 0)
+	if d := ruler.Distance(left, ruler.ClosestOnBbox(left, bbox)); math.Abs(d-100) > 1e-6 {
+		t.Fatalf("%f != %f", d, 100.)
+	}
+
+	t.Log("OK")
+}
+
+func TestRingArea(t *testing.T) {
+	t.Log("ruler ring area is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	ring := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100), ruler.Offset(a, 0, 100), a}
+	area := ruler.RingArea(ring)
+
+	if math.Abs(area-10000) > 1e-6 {
+		t.Fatalf("%f != %f", area, 10000.)
+	}
+
+	if reversed := ruler.RingArea(ruler.Reverse(ring)); math.Abs(reversed-area) > 1e-9 {
+		t.Fatalf("%f != %f", reversed, area)
+	}
+
+	if polygonArea := ruler.Area(Polygon{ring}); math.Abs(polygonArea-area) > 1e-9 {
+		t.Fatalf("%f != %f", polygonArea, area)
+	}
+
+	t.Log("OK", area)
+}
+
+func TestWinding(t *testing.T) {
+	t.Log("ruler winding detection is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	counterClockwise := ruler.BboxToLine(Bbox{2.350, 48.862, 2.352, 48.864})
+	clockwise := ruler.Reverse(counterClockwise)
+
+	if ruler.IsClockwise(counterClockwise) || !ruler.IsClockwise(clockwise) {
+		t.Fatalf("winding of %+v is not detected", counterClockwise)
+	}
+
+	if !ruler.IsClockwise(ruler.EnsureWinding(counterClockwise, true)) ||
+		!ruler.IsClockwise(ruler.EnsureWinding(clockwise, true)) ||
+		ruler.IsClockwise(ruler.EnsureWinding(counterClockwise, false)) ||
+		ruler.IsClockwise(ruler.EnsureWinding(clockwise, false)) {
+		t.Fatalf("winding of %+v is not enforced", counterClockwise)
+	}
+
+	t.Log("OK")
+}
+
+func TestTriangleArea(t *testing.T) {
+	t.Log("ruler triangle area is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	b := ruler.Offset(a, 30, 0)
+	c := ruler.Offset(a, 0, 40)
+
+	if area := ruler.TriangleArea(a, b, c); math.Abs(area-600) > 1e-6 {
+		t.Fatalf("%f != %f", area, 600.)
+	}
+	if area := ruler.TriangleArea(a, c, b); math.Abs(area-600) > 1e-6 {
+		t.Fatalf("%f != %f", area, 600.)
+	}
+	if area, expected := ruler.TriangleArea(a, b, c), ruler.Area(Polygon{Line{a, b, c, a}}); math.Abs(area-expected) > 1e-6 {
+		t.Fatalf("%f != %f", area, expected)
+	}
+	if area := ruler.TriangleArea(a, b, ruler.Offset(a, 60, 0)); math.Abs(area) > 1e-6 {
+		t.Fatalf("%f != 0", area)
+	}
+
+	t.Log("OK")
+}
+
+func TestAreaErr(t *testing.T) {
+	t.Log("ruler area with errors is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	polygon := ruler.BboxToPolygon(Bbox{2.350, 48.862, 2.352, 48.864})
+
+	area, err := ruler.AreaErr(polygon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if area != ruler.Area(polygon) {
+		t.Fatalf("%f != %f", area, ruler.Area(polygon))
+	}
+
+	if _, err := ruler.AreaErr(Polygon{polygon[0], Line{Point{2.351, 48.863}}}); err == nil {
+		t.Fatalf("a one-point ring should return an error")
+	}
+
+	if _, err := ruler.AreaErr(Polygon{}); err == nil {
+		t.Fatalf("a polygon without an outer ring should return an error")
+	}
+
+	t.Log("OK", area)
+}
+
+func TestSignedArea(t *testing.T) {
+	t.Log("ruler signed area is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	outer := Line{a, ruler.Offset(a, 300, 0), ruler.Offset(a, 300, 300), ruler.Offset(a, 0, 300), a}
+	hole := Line{ruler.Offset(a, 100, 100), ruler.Offset(a, 100, 200), ruler.Offset(a, 200, 200), ruler.Offset(a, 200, 100), ruler.Offset(a, 100, 100)}
+
+	if area := ruler.SignedRingArea(outer); math.Abs(area-90000) > 1e-6 {
+		t.Fatalf("%f != %f", area, 90000.)
+	}
+
+	if area := ruler.SignedRingArea(hole); math.Abs(area+10000) > 1e-6 {
+		t.Fatalf("%f != %f", area, -10000.)
+	}
+
+	if area := ruler.SignedArea(Polygon{outer, hole}); math.Abs(area-80000) > 1e-6 {
+		t.Fatalf("%f != %f", area, 80000.)
+	}
+
+	if area := ruler.SignedArea(Polygon{ruler.Reverse(outer)}); math.Abs(area+90000) > 1e-6 {
+		t.Fatalf("%f != %f", area, -90000.)
+	}
+
+	t.Log("OK")
+}
+
+func TestIntersectionArea(t *testing.T) {
+	t.Log("ruler intersection area is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	square := func(origin Point, size float64) Polygon {
+		return Polygon{Line{
+			origin,
+			ruler.Offset(origin, size, 0),
+			ruler.Offset(origin, size, size),
+			ruler.Offset(origin, 0, size),
+			origin,
+		}}
+	}
+
+	a := Point{2.350, 48.862}
+	base := square(a, 100)
+
+	cases := []struct {
+		a        Polygon
+		b        Polygon
+		expected float64
+	}{
+		{base, square(ruler.Offset(a, 50, 50), 100), 2500},
+		{base, square(ruler.Offset(a, 200, 0), 100), 0},
+		{base, square(ruler.Offset(a, 25, 25), 50), 2500},
+		{square(ruler.Offset(a, 25, 25), 50), base, 2500},
+		{base, Polygon{ruler.Reverse(square(ruler.Offset(a, 50, -50), 100)[0])}, 2500},
+		{base, base, 10000},
+		{base, Polygon{}, 0},
+	}
+
+	for _, c := range cases {
+		if area := ruler.IntersectionArea(c.a, c.b); math.Abs(area-c.expected) > 1e-3 {
+			t.Fatalf("%f != %f", area, c.expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestBboxToPolygon(t *testing.T) {
+	t.Log("ruler bbox to polygon is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	bbox := Bbox{2.349946, 48.862990, 2.350162, 48.863318}
+	polygon := ruler.BboxToPolygon(bbox)
+
+	if len(polygon) != 1 || len(polygon[0]) != 5 || polygon[0][0] != polygon[0][4] {
+		t.Fatalf("%+v is not a closed ring of five points", polygon)
+	}
+
+	area := ruler.Area(polygon)
+	expected := (bbox[2] - bbox[0]) * ruler.kx * (bbox[3] - bbox[1]) * ruler.ky
+
+	if math.Abs(area-expected) > 1e-6 {
+		t.Fatalf("%f != %f", area, expected)
+	}
+
+	t.Log("OK", polygon)
+}
+
+func TestLineBbox(t *testing.T) {
+	t.Log("ruler line bbox is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	bbox := ruler.LineBbox(testLine)
+	expected := Bbox{2.3469865, 48.862147, 2.3503875, 48.863598}
+
+	if bbox != expected {
+		t.Fatalf("%f != %f", bbox, expected)
+	}
+
+	polygonBbox := ruler.PolygonBbox(Polygon{testLine})
+	if polygonBbox != expected {
+		t.Fatalf("%f != %f", polygonBbox, expected)
+	}
+
+	if ruler.LineBbox(Line{}) != (Bbox{}) || ruler.PolygonBbox(Polygon{}) != (Bbox{}) {
+		t.Fatalf("empty geometries should return a zero bbox")
+	}
+
+	t.Log("OK", bbox)
+}
+
+func TestDensify(t *testing.T) {
+	t.Log("ruler densify is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	dense := ruler.Densify(testLine, 20)
+
+	for i := 0; i < len(dense)-1; i++ {
+		if d := ruler.Distance(dense[i], dense[i+1]); d > 20+1e-9 {
+			t.Fatalf("segment %d is %f long", i, d)
+		}
+	}
+
+	j := 0
+	for _, p := range dense {
+		if j < len(testLine) && p == testLine[j] {
+			j++
+		}
+	}
+	if j != len(testLine) {
+		t.Fatalf("original points are not preserved in order")
+	}
+
+	if math.Abs(ruler.LineDistance(dense)-ruler.LineDistance(testLine)) > 1e-6 {
+		t.Fatalf("%f != %f", ruler.LineDistance(dense), ruler.LineDistance(testLine))
+	}
+
+	if unchanged := ruler.Densify(testLine, 0); len(unchanged) != len(testLine) {
+		t.Fatalf("%+v != %+v", unchanged, testLine)
+	}
+
+	t.Log("OK", len(dense))
+}
+
+func TestSegmentIntersection(t *testing.T) {
+	t.Log("ruler segment intersection is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a1 := Point{2.350, 48.862}
+	a2 := Point{2.352, 48.864}
+
+	p, ok := ruler.SegmentIntersection(a1, a2, Point{2.350, 48.864}, Point{2.352, 48.862})
+	expected := Point{2.351, 48.863}
+	if !ok || math.Abs(p[0]-expected[0]) > 1e-9 || math.Abs(p[1]-expected[1]) > 1e-9 {
+		t.Fatalf("%+v, %t != %+v, true", p, ok, expected)
+	}
+
+	if _, ok := ruler.SegmentIntersection(a1, a2, Point{2.351, 48.862}, Point{2.353, 48.864}); ok {
+		t.Fatalf("parallel segments should not intersect")
+	}
+
+	if _, ok := ruler.SegmentIntersection(a1, a2, Point{2.351, 48.863}, Point{2.353, 48.865}); ok {
+		t.Fatalf("collinear segments should not intersect")
+	}
+
+	if _, ok := ruler.SegmentIntersection(a1, a2, Point{2.353, 48.862}, Point{2.352, 48.863}); ok {
+		t.Fatalf("segments whose lines cross beyond their ends should not intersect")
+	}
+
+	p, ok = ruler.SegmentIntersection(a1, a2, a2, Point{2.354, 48.862})
+	if !ok || p != a2 {
+		t.Fatalf("%+v, %t != %+v, true", p, ok, a2)
+	}
+
+	t.Log("OK", p)
+}
+
+func TestLineIntersections(t *testing.T) {
+	t.Log("ruler line intersections are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	zigzag := Line{
+		Point{2.350, 48.862},
+		Point{2.351, 48.864},
+		Point{2.352, 48.862},
+		Point{2.353, 48.864},
+	}
+
+	cases := []struct {
+		line     Line
+		expected int
+	}{
+		{Line{Point{2.350, 48.865}, Point{2.353, 48.865}}, 0},
+		{Line{Point{2.350, 48.863}, Point{2.3505, 48.863}, Point{2.3505, 48.865}}, 1},
+		{Line{Point{2.349, 48.863}, Point{2.354, 48.863}}, 3},
+		{Line{Point{2.351, 48.864}, Point{2.351, 48.865}}, 1},
+		{Line{Point{2.349, 48.864}, Point{2.354, 48.864}}, 2},
+	}
+
+	for _, c := range cases {
+		intersections := ruler.LineIntersections(zigzag, c.line)
+		if len(intersections) != c.expected {
+			t.Fatalf("%+v should have %d intersections", intersections, c.expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestReverse(t *testing.T) {
+	t.Log("ruler reverse is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	original := append(Line{}, testLine...)
+	reversed := ruler.Reverse(testLine)
+
+	if reversed[0] != testLine[len(testLine)-1] || reversed[len(reversed)-1] != testLine[0] {
+		t.Fatalf("%+v is not reversed", reversed)
+	}
+
+	if ruler.LineDistance(reversed) != ruler.LineDistance(testLine) {
+		t.Fatalf("%f != %f", ruler.LineDistance(reversed), ruler.LineDistance(testLine))
+	}
+
+	twice := ruler.Reverse(reversed)
+	for i := range testLine {
+		if twice[i] != testLine[i] || testLine[i] != original[i] {
+			t.Fatalf("%+v != %+v", twice, testLine)
+		}
+	}
+
+	t.Log("OK", reversed)
+}
+
+func TestScale(t *testing.T) {
+	t.Log("ruler scale is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	square := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100), ruler.Offset(a, 0, 100), a}
+	center := ruler.Offset(a, 50, 50)
+
+	scaled := ruler.Scale(square, center, 2)
+	if area, expected := ruler.RingArea(scaled), 4*ruler.RingArea(square); math.Abs(area-expected) > 1e-6 {
+		t.Fatalf("%f != %f", area, expected)
+	}
+	if p := ruler.MeanCenter(scaled[:4]); ruler.Distance(p, center) > 1e-6 {
+		t.Fatalf("%+v != %+v", p, center)
+	}
+	if d := ruler.Distance(scaled[0], ruler.Offset(a, -50, -50)); d > 1e-6 {
+		t.Fatalf("%f should be close to 0", d)
+	}
+
+	shrunk := ruler.Scale(square, a, 0.5)
+	if d := ruler.Distance(shrunk[2], center); d > 1e-6 {
+		t.Fatalf("%f should be close to 0", d)
+	}
+	if square[2] != ruler.Offset(a, 100, 100) {
+		t.Fatalf("%+v should not be modified", square)
+	}
+
+	if empty := ruler.Scale(Line{}, a, 2); len(empty) != 0 {
+		t.Fatalf("%+v should be empty", empty)
+	}
+
+	t.Log("OK", scaled)
+}
+
+func TestTranslate(t *testing.T) {
+	t.Log("ruler translate is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	translated := ruler.Translate(testLine, 100, 45)
+
+	if len(translated) != len(testLine) {
+		t.Fatalf("%d != %d", len(translated), len(testLine))
+	}
+
+	for i := range testLine {
+		if d := ruler.Distance(testLine[i], translated[i]); math.Abs(d-100) > 1e-6 {
+			t.Fatalf("%f != %f", d, 100.)
+		}
+		if b := ruler.Bearing(testLine[i], translated[i]); math.Abs(b-45) > 1e-6 {
+			t.Fatalf("%f != %f", b, 45.)
+		}
+	}
+
+	if d, expected := ruler.LineDistance(translated), ruler.LineDistance(testLine); math.Abs(d-expected) > 1e-6 {
+		t.Fatalf("%f != %f", d, expected)
+	}
+
+	t.Log("OK")
+}
+
+func TestTotalTurn(t *testing.T) {
+	t.Log("ruler total turn is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	straight := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 200, 0)}
+	corner := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100)}
+	zigzag := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100), ruler.Offset(a, 200, 100)}
+
+	cases := []struct {
+		line     Line
+		expected float64
+	}{
+		{straight, 0},
+		{corner, 90},
+		{zigzag, 180},
+		{Line{a, ruler.Offset(a, 100, 0)}, 0},
+	}
+
+	for _, c := range cases {
+		if turn := ruler.TotalTurn(c.line); math.Abs(turn-c.expected) > 1e-6 {
+			t.Fatalf("%f != %f", turn, c.expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestTurnAngle(t *testing.T) {
+	t.Log("ruler turn angle is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	left := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100)}
+	right := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, -100)}
+
+	cases := []struct {
+		line     Line
+		i        int
+		expected float64
+	}{
+		{left, 1, -90},
+		{right, 1, 90},
+		{left, 0, 0},
+		{left, 2, 0},
+		{left, 5, 0},
+		{Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 200, 0)}, 1, 0},
+	}
+
+	for _, c := range cases {
+		if turn := ruler.TurnAngle(c.line, c.i); math.Abs(turn-c.expected) > 1e-6 {
+			t.Fatalf("%f != %f", turn, c.expected)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestBboxCenterAndArea(t *testing.T) {
+	t.Log("ruler bbox center and area are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	b := ruler.Offset(a, 200, 100)
+	bbox := Bbox{a[0], a[1], b[0], b[1]}
+
+	center := ruler.BboxCenter(bbox)
+	expected := ruler.Offset(a, 100, 50)
+	if math.Abs(center[0]-expected[0]) > 1e-9 || math.Abs(center[1]-expected[1]) > 1e-9 {
+		t.Fatalf("%+v != %+v", center, expected)
+	}
+
+	if area := ruler.BboxArea(bbox); math.Abs(area-20000) > 1e-6 {
+		t.Fatalf("%f != %f", area, 20000.)
+	}
+
+	t.Log("OK", center)
+}
+
+func TestExtendBbox(t *testing.T) {
+	t.Log("ruler extend bbox is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	bbox := EmptyBbox()
+
+	for _, p := range testLine {
+		if ruler.InsideBbox(p, bbox) {
+			t.Fatalf("%+v should not be inside %+v yet", p, bbox)
+		}
+		bbox = ruler.ExtendBbox(bbox, p)
+		if !ruler.InsideBbox(p, bbox) {
+			t.Fatalf("%+v should be inside %+v", p, bbox)
+		}
+	}
+
+	if expected := ruler.LineBbox(testLine); bbox != expected {
+		t.Fatalf("%f != %f", bbox, expected)
+	}
+
+	t.Log("OK", bbox)
+}
+
+func TestBboxIntersectsAndContains(t *testing.T) {
+	t.Log("ruler bbox intersects and contains are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	bbox := Bbox{2.350, 48.862, 2.352, 48.864}
+
+	cases := []struct {
+		other      Bbox
+		intersects bool
+		contains   bool
+	}{
+		{Bbox{2.351, 48.863, 2.353, 48.865}, true, false},
+		{Bbox{2.353, 48.863, 2.354, 48.865}, false, false},
+		{Bbox{2.352, 48.864, 2.353, 48.865}, true, false},
+		{Bbox{2.3505, 48.8625, 2.3515, 48.8635}, true, true},
+		{bbox, true, true},
+	}
+
+	for _, c := range cases {
+		if ruler.BboxIntersects(bbox, c.other) != c.intersects || ruler.BboxIntersects(c.other, bbox) != c.intersects {
+			t.Fatalf("%f intersects %f: %t", bbox, c.other, c.intersects)
+		}
+		if ruler.BboxContains(bbox, c.other) != c.contains {
+			t.Fatalf("%f contains %f: %t", bbox, c.other, c.contains)
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestResampleN(t *testing.T) {
+	t.Log("ruler resample is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	line := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 250, 0)}
+	resampled, err := ruler.ResampleN(line, 6)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resampled) != 6 || resampled[0] != line[0] || resampled[5] != line[2] {
+		t.Fatalf("%+v should have 6 points from %+v to %+v", resampled, line[0], line[2])
+	}
+
+	for i := 0; i < len(resampled)-1; i++ {
+		if d := ruler.Distance(resampled[i], resampled[i+1]); math.Abs(d-50) > 1e-6 {
+			t.Fatalf("%f != %f", d, 50.)
+		}
+	}
+
+	if _, err := ruler.ResampleN(line, 1); err == nil {
+		t.Fatalf("fewer than 2 points should return an error")
+	}
+
+	t.Log("OK", resampled)
+}
+
+func TestSampleAlong(t *testing.T) {
+	t.Log("ruler sample along is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	line := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 250, 0)}
+	samples, err := ruler.SampleAlong(line, 30)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 10 {
+		t.Fatalf("%d != %d", len(samples), 10)
+	}
+
+	for i := 0; i < len(samples)-2; i++ {
+		if d := ruler.Distance(samples[i], samples[i+1]); math.Abs(d-30) > 1e-6 {
+			t.Fatalf("%f != %f", d, 30.)
+		}
+	}
+
+	if samples[0] != line[0] || samples[len(samples)-1] != line[len(line)-1] {
+		t.Fatalf("%+v should start and end with the line endpoints", samples)
+	}
+
+	if _, err := ruler.SampleAlong(line, 0); err == nil {
+		t.Fatalf("non-positive interval should return an error")
+	}
+
+	t.Log("OK", samples)
+}
+
+func TestConvexHull(t *testing.T) {
+	t.Log("ruler convex hull is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	points := []Point{
+		Point{2.351, 48.863},
+		Point{2.352, 48.864},
+		Point{2.350, 48.862},
+		Point{2.352, 48.862},
+		Point{2.351, 48.862},
+		Point{2.350, 48.864},
+	}
+	hull := ruler.ConvexHull(points)
+	expected := Line{
+		Point{2.350, 48.862},
+		Point{2.352, 48.862},
+		Point{2.352, 48.864},
+		Point{2.350, 48.864},
+		Point{2.350, 48.862},
+	}
+
+	if len(hull) != 1 || len(hull[0]) != len(expected) {
+		t.Fatalf("%+v != %+v", hull, expected)
+	}
+	for i := range expected {
+		if hull[0][i] != expected[i] {
+			t.Fatalf("%+v != %+v", hull, expected)
+		}
+	}
+
+	if small := ruler.ConvexHull(points[:2]); len(small) != 1 || len(small[0]) != 2 {
+		t.Fatalf("%+v should contain the two points", small)
+	}
+
+	if empty := ruler.ConvexHull(nil); len(empty) != 0 {
+		t.Fatalf("%+v should be empty", empty)
+	}
+
+	t.Log("OK", hull)
+}
+
+func TestClipToBbox(t *testing.T) {
+	t.Log("ruler clip to bbox is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	bbox := Bbox{2.350, 48.862, 2.352, 48.864}
+
+	cases := []struct {
+		line     Line
+		expected []Line
+	}{
+		{
+			Line{Point{2.3505, 48.8625}, Point{2.3515, 48.8635}},
+			[]Line{Line{Point{2.3505, 48.8625}, Point{2.3515, 48.8635}}},
+		},
+		{
+			Line{Point{2.349, 48.863}, Point{2.351, 48.863}},
+			[]Line{Line{Point{2.350, 48.863}, Point{2.351, 48.863}}},
+		},
+		{
+			Line{Point{2.351, 48.863}, Point{2.353, 48.863}},
+			[]Line{Line{Point{2.351, 48.863}, Point{2.352, 48.863}}},
+		},
+		{
+			Line{Point{2.351, 48.861}, Point{2.351, 48.863}, Point{2.351, 48.865}},
+			[]Line{Line{Point{2.351, 48.862}, Point{2.351, 48.863}, Point{2.351, 48.864}}},
+		},
+		{
+			Line{Point{2.349, 48.863}, Point{2.351, 48.863}, Point{2.351, 48.866}, Point{2.3515, 48.866}, Point{2.3515, 48.863}},
+			[]Line{
+				Line{Point{2.350, 48.863}, Point{2.351, 48.863}, Point{2.351, 48.864}},
+				Line{Point{2.3515, 48.864}, Point{2.3515, 48.863}},
+			},
+		},
+		{
+			Line{Point{2.349, 48.861}, Point{2.353, 48.861}},
+			nil,
+		},
+		{
+			Line{Point{2.349, 48.863}, Point{2.350, 48.863}, Point{2.351, 48.863}},
+			[]Line{Line{Point{2.350, 48.863}, Point{2.351, 48.863}}},
+		},
+		{
+			Line{Point{2.349, 48.863}, Point{2.350, 48.863}, Point{2.349, 48.8635}},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		parts := ruler.ClipToBbox(c.line, bbox)
+		if len(parts) != len(c.expected) {
+			t.Fatalf("%+v != %+v", parts, c.expected)
+		}
+		for i := range c.expected {
+			if len(parts[i]) != len(c.expected[i]) {
+				t.Fatalf("%+v != %+v", parts, c.expected)
+			}
+			for j := range c.expected[i] {
+				if math.Abs(parts[i][j][0]-c.expected[i][j][0]) > 1e-9 || math.Abs(parts[i][j][1]-c.expected[i][j][1]) > 1e-9 {
+					t.Fatalf("%+v != %+v", parts, c.expected)
+				}
+			}
+		}
+	}
+
+	t.Log("OK")
+}
+
+func TestClone(t *testing.T) {
+	t.Log("ruler clones do not share memory with the original")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	original := testLine[0]
+
+	clone := ruler.CloneLine(testLine)
+	clone[0][0] = 0
+	if testLine[0] != original {
+		t.Fatalf("%+v != %+v", testLine[0], original)
+	}
+
+	polygon := Polygon{testLine}
+	polygonClone := ruler.ClonePolygon(polygon)
+	polygonClone[0][0][0] = 0
+	if polygon[0][0] != original {
+		t.Fatalf("%+v != %+v", polygon[0][0], original)
+	}
+
+	slice := ruler.LineSlice(testLine[0], testLine[len(testLine)-1], testLine)
+	slice[0][0] = 0
+	sliceAlong := ruler.LineSliceAlong(0, 1000, testLine)
+	sliceAlong[0][0] = 0
+	if testLine[0] != original {
+		t.Fatalf("%+v != %+v", testLine[0], original)
+	}
+
+	t.Log("OK")
+}
+
+func TestCloseRings(t *testing.T) {
+	t.Log("ruler close rings is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	open := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100), ruler.Offset(a, 0, 100)}
+	closedHole := Line{ruler.Offset(a, 25, 25), ruler.Offset(a, 25, 75), ruler.Offset(a, 75, 75), ruler.Offset(a, 25, 25)}
+	polygon := Polygon{open, closedHole, Line{}}
+
+	if ruler.IsClosed(open) || !ruler.IsClosed(closedHole) || ruler.IsClosed(Line{}) {
+		t.Fatal("rings are not detected as closed correctly")
+	}
+
+	closed := ruler.CloseRings(polygon)
+	if len(closed) != 3 || len(closed[0]) != len(open)+1 || len(closed[1]) != len(closedHole) || len(closed[2]) != 0 {
+		t.Fatalf("%+v has unexpected ring lengths", closed)
+	}
+	if !ruler.IsClosed(closed[0]) || !ruler.IsClosed(closed[1]) {
+		t.Fatalf("%+v should be closed", closed)
+	}
+	if len(polygon[0]) != len(open) {
+		t.Fatalf("%+v should not be modified", polygon)
+	}
+
+	if area, expected := ruler.Area(closed), ruler.Area(polygon); math.Abs(area-expected) > 1e-6 {
+		t.Fatalf("%f != %f", area, expected)
+	}
+
+	t.Log("OK")
+}
+
+func TestFrechetDistance(t *testing.T) {
+	t.Log("ruler Fréchet distance is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+
+	if d := ruler.FrechetDistance(testLine, testLine); d != 0 {
+		t.Fatalf("%f != %f", d, 0.)
+	}
+
+	a := Point{2.350, 48.862}
+	line := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 200, 0)}
+	offset := Line{ruler.Offset(a, 0, 15), ruler.Offset(a, 100, 15), ruler.Offset(a, 200, 15)}
+
+	if d := ruler.FrechetDistance(line, offset); math.Abs(d-15) > 1e-6 {
+		t.Fatalf("%f != %f", d, 15.)
+	}
+
+	if d := ruler.FrechetDistance(line, ruler.Reverse(line)); math.Abs(d-200) > 1e-6 {
+		t.Fatalf("%f != %f", d, 200.)
+	}
+
+	t.Log("OK")
+}
+
+func TestHausdorffDistance(t *testing.T) {
+	t.Log("ruler Hausdorff distance is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+
+	if d := ruler.HausdorffDistance(testLine, testLine); d != 0 {
+		t.Fatalf("%f != %f", d, 0.)
+	}
+
+	a := Point{2.350, 48.862}
+	line := Line{a, ruler.Offset(a, 200, 0)}
+	divergent := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 150, 40), ruler.Offset(a, 200, 0)}
+
+	if d := ruler.HausdorffDistance(line, divergent); math.Abs(d-40) > 1e-6 {
+		t.Fatalf("%f != %f", d, 40.)
+	}
+
+	if d := ruler.HausdorffDistance(divergent, line); math.Abs(d-40) > 1e-6 {
+		t.Fatalf("%f != %f", d, 40.)
+	}
+
+	t.Log("OK")
+}
+
+func TestSimplifyVW(t *testing.T) {
+	t.Log("ruler Visvalingam-Whyatt simplification is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+
+	var zigzag Line
+	for i := 0; i <= 10; i++ {
+		dy := float64(i % 2)
+		if i == 5 {
+			dy = 50
+		}
+		zigzag = append(zigzag, ruler.Offset(a, float64(i)*10, dy))
+	}
+
+	simplified := ruler.SimplifyVW(zigzag, 100)
+	expected := Line{zigzag[0], zigzag[4], zigzag[5], zigzag[6], zigzag[10]}
+	if len(simplified) != len(expected) {
+		t.Fatalf("%+v != %+v", simplified, expected)
+	}
+	for i := range expected {
+		if simplified[i] != expected[i] {
+			t.Fatalf("%+v != %+v", simplified, expected)
+		}
+	}
+
+	if kept := ruler.SimplifyVW(zigzag, 5); len(kept) != len(zigzag) {
+		t.Fatalf("%d != %d", len(kept), len(zigzag))
+	}
+
+	straight := Line{a, ruler.Offset(a, 50, 0), ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100)}
+	if collinear := ruler.SimplifyVW(straight, 0); len(collinear) != 3 || collinear[1] != straight[2] {
+		t.Fatalf("%+v should only drop the collinear point", collinear)
+	}
+
+	if short := ruler.SimplifyVW(zigzag[:2], 1e9); len(short) != 2 {
+		t.Fatalf("%+v should be unchanged", short)
+	}
+
+	t.Log("OK", len(simplified))
+}
+
+func TestJoin(t *testing.T) {
+	t.Log("ruler join is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := testLine[:3]
+	b := testLine[2:]
+
+	joined := ruler.Join(a, b)
+	if len(joined) != len(testLine) {
+		t.Fatalf("%+v != %+v", joined, testLine)
+	}
+	for i := range testLine {
+		if joined[i] != testLine[i] {
+			t.Fatalf("%+v != %+v", joined, testLine)
+		}
+	}
+
+	aligned := ruler.JoinAligned(a, ruler.Reverse(b))
+	if len(aligned) != len(testLine) {
+		t.Fatalf("%+v != %+v", aligned, testLine)
+	}
+	for i := range testLine {
+		if aligned[i] != testLine[i] {
+			t.Fatalf("%+v != %+v", aligned, testLine)
+		}
+	}
+
+	disconnected := ruler.JoinAligned(testLine[:2], testLine[3:])
+	if len(disconnected) != len(testLine)-1 || disconnected[2] != testLine[3] {
+		t.Fatalf("%+v should contain all the points of both lines", disconnected)
+	}
+
+	if len(testLine) != 6 || testLine[2] != b[0] {
+		t.Fatalf("joining should not modify the original lines")
+	}
+
+	t.Log("OK", joined)
+}
+
+func TestSplitAtDistance(t *testing.T) {
+	t.Log("ruler split at distance is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	total := ruler.LineDistance(testLine)
+	before, after := ruler.SplitAtDistance(testLine, 150)
+
+	if math.Abs(ruler.LineDistance(before)-150) > 1e-6 || math.Abs(ruler.LineDistance(after)-(total-150)) > 1e-6 {
+		t.Fatalf("%f, %f != %f, %f", ruler.LineDistance(before), ruler.LineDistance(after), 150., total-150)
+	}
+
+	if before[len(before)-1] != after[0] || before[len(before)-1] != ruler.Along(testLine, 150) {
+		t.Fatalf("%+v and %+v should share the split point", before, after)
+	}
+
+	vertex := Line{{0, 0}, {1, 0}, {2, 0}}
+	if before, after := ruler.SplitAtDistance(vertex, ruler.Distance(vertex[0], vertex[1])); len(before) != 2 || len(after) != 2 ||
+		before[1] != vertex[1] || after[0] != vertex[1] || after[1] != vertex[2] {
+		t.Fatalf("%+v, %+v should be split at %+v", before, after, vertex[1])
+	}
+
+	if before, after := ruler.SplitAtDistance(testLine, 0); len(before) != 0 || len(after) != len(testLine) {
+		t.Fatalf("%+v, %+v should be empty and the full line", before, after)
+	}
+
+	if before, after := ruler.SplitAtDistance(testLine, total+1); len(before) != len(testLine) || len(after) != 0 {
+		t.Fatalf("%+v, %+v should be the full line and empty", before, after)
+	}
+
+	t.Log("OK", before, after)
+}
+
+func TestBoundingCircle(t *testing.T) {
+	t.Log("ruler bounding circle is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	center, radius := ruler.BoundingCircle(testLine)
+
+	for _, p := range testLine {
+		if d := ruler.Distance(center, p); d > radius+1e-6 {
+			t.Fatalf("%+v is %f from the center, outside of the radius %f", p, d, radius)
+		}
+	}
+
+	a := Point{2.350, 48.862}
+	square := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100), ruler.Offset(a, 0, 100), ruler.Offset(a, 50, 50)}
+	center, radius = ruler.BoundingCircle(square)
+	expected := ruler.Offset(a, 50, 50)
+
+	if math.Abs(radius-math.Sqrt(5000)) > 1e-6 || ruler.Distance(center, expected) > 1e-6 {
+		t.Fatalf("%+v, %f != %+v, %f", center, radius, expected, math.Sqrt(5000))
+	}
+
+	t.Log("OK", center, radius)
+}
+
+func TestClosestBetweenLines(t *testing.T) {
+	t.Log("ruler closest points between lines are correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	line := Line{a, ruler.Offset(a, 200, 0)}
+	crossing := Line{ruler.Offset(a, 50, -50), ruler.Offset(a, 50, 50)}
+
+	p, q, d := ruler.ClosestBetweenLines(line, crossing)
+	expected := ruler.Offset(a, 50, 0)
+	if d != 0 || p != q || ruler.Distance(p, expected) > 1e-6 {
+		t.Fatalf("%+v, %+v, %f != %+v, %+v, 0", p, q, d, expected, expected)
+	}
+
+	apart := Line{ruler.Offset(a, 250, 30), ruler.Offset(a, 100, 30)}
+	p, q, d = ruler.ClosestBetweenLines(line, apart)
+	if math.Abs(d-30) > 1e-6 || math.Abs(ruler.Distance(p, q)-30) > 1e-6 {
+		t.Fatalf("%f != %f", d, 30.)
+	}
+
+	t.Log("OK", p, q, d)
+}
+
+func TestOffsetLine(t *testing.T) {
+	t.Log("ruler offset line is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	line := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 200, 0)}
+	offset := ruler.OffsetLine(line, 10)
+
+	for i := range line {
+		expected := ruler.Offset(line[i], 0, -10)
+		if ruler.Distance(offset[i], expected) > 1e-6 {
+			t.Fatalf("%+v != %+v", offset[i], expected)
+		}
+	}
+
+	left := ruler.OffsetLine(line, -10)
+	if expected := ruler.Offset(line[1], 0, 10); ruler.Distance(left[1], expected) > 1e-6 {
+		t.Fatalf("%+v != %+v", left[1], expected)
+	}
+
+	corner := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 100, 100)}
+	mitered := ruler.OffsetLine(corner, 10)
+	if expected := ruler.Offset(a, 110, -10); ruler.Distance(mitered[1], expected) > 1e-6 {
+		t.Fatalf("%+v != %+v", mitered[1], expected)
+	}
+
+	for _, p := range []Point{mitered[0], mitered[2]} {
+		if d := ruler.DistanceToLine(corner, p); math.Abs(d-10) > 1e-6 {
+			t.Fatalf("%+v is %f from the line", p, d)
+		}
+	}
+
+	uTurn := Line{a, ruler.Offset(a, 100, 0), a}
+	beveled := ruler.OffsetLine(uTurn, 10)
+	if len(beveled) != 4 {
+		t.Fatalf("%d != %d", len(beveled), 4)
+	}
+	for _, p := range beveled {
+		if d := ruler.DistanceToLine(uTurn, p); math.Abs(d-10) > 1e-6 {
+			t.Fatalf("%+v is %f from the line", p, d)
+		}
+	}
+
+	t.Log("OK", offset)
+}
+
+func TestLabelAnchor(t *testing.T) {
+	t.Log("ruler label anchor is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	a := Point{2.350, 48.862}
+	line := Line{a, ruler.Offset(a, 100, 0), ruler.Offset(a, 200, 0)}
+
+	anchor, bearing := ruler.LabelAnchor(line, 150, 10)
+	expected := ruler.Offset(a, 150, -10)
+	if ruler.Distance(anchor, expected) > 1e-6 || math.Abs(bearing-90) > 1e-9 {
+		t.Fatalf("%+v, %f != %+v, %f", anchor, bearing, expected, 90.)
+	}
+
+	anchor, _ = ruler.LabelAnchor(line, 50, -10)
+	expected = ruler.Offset(a, 50, 10)
+	if ruler.Distance(anchor, expected) > 1e-6 {
+		t.Fatalf("%+v != %+v", anchor, expected)
+	}
+
+	anchor, bearing = ruler.LabelAnchor(ruler.Reverse(line), 50, 10)
+	expected = ruler.Offset(a, 150, 10)
+	if ruler.Distance(anchor, expected) > 1e-6 || math.Abs(bearing+90) > 1e-9 {
+		t.Fatalf("%+v, %f != %+v, %f", anchor, bearing, expected, -90.)
+	}
+
+	t.Log("OK", anchor, bearing)
 }
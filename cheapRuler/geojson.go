@@ -0,0 +1,105 @@
+package cheapRuler
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// geometry is the GeoJSON geometry object used to marshal and unmarshal points, lines and polygons.
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// MarshalJSON encodes the point as a GeoJSON Point geometry.
+func (p Point) MarshalJSON() ([]byte, error) {
+	return marshalGeometry("Point", [2]float64(p))
+}
+
+// UnmarshalJSON decodes a GeoJSON Point geometry into the point.
+func (p *Point) UnmarshalJSON(data []byte) error {
+	var coordinates [2]float64
+	if err := unmarshalGeometry(data, "Point", &coordinates); err != nil {
+		return err
+	}
+	*p = Point(coordinates)
+	return nil
+}
+
+// MarshalJSON encodes the line as a GeoJSON LineString geometry.
+func (l Line) MarshalJSON() ([]byte, error) {
+	return marshalGeometry("LineString", lineCoordinates(l))
+}
+
+// UnmarshalJSON decodes a GeoJSON LineString geometry into the line.
+func (l *Line) UnmarshalJSON(data []byte) error {
+	var coordinates [][2]float64
+	if err := unmarshalGeometry(data, "LineString", &coordinates); err != nil {
+		return err
+	}
+	*l = coordinatesLine(coordinates)
+	return nil
+}
+
+// MarshalJSON encodes the polygon as a GeoJSON Polygon geometry.
+func (p Polygon) MarshalJSON() ([]byte, error) {
+	coordinates := make([][][2]float64, len(p))
+	for i, ring := range p {
+		coordinates[i] = lineCoordinates(ring)
+	}
+	return marshalGeometry("Polygon", coordinates)
+}
+
+// UnmarshalJSON decodes a GeoJSON Polygon geometry into the polygon.
+func (p *Polygon) UnmarshalJSON(data []byte) error {
+	var coordinates [][][2]float64
+	if err := unmarshalGeometry(data, "Polygon", &coordinates); err != nil {
+		return err
+	}
+	polygon := make(Polygon, len(coordinates))
+	for i, ring := range coordinates {
+		polygon[i] = coordinatesLine(ring)
+	}
+	*p = polygon
+	return nil
+}
+
+// marshalGeometry encodes a GeoJSON geometry object of the given type and coordinates.
+func marshalGeometry(geometryType string, coordinates interface{}) ([]byte, error) {
+	raw, err := json.Marshal(coordinates)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(geometry{Type: geometryType, Coordinates: raw})
+}
+
+// unmarshalGeometry decodes the coordinates of a GeoJSON geometry object,
+// returning an error if the geometry is not of the expected type.
+func unmarshalGeometry(data []byte, geometryType string, coordinates interface{}) error {
+	var g geometry
+	if err := json.Unmarshal(data, &g); err != nil {
+		return err
+	}
+	if g.Type != geometryType {
+		return errors.New("expected a GeoJSON " + geometryType + " geometry, got " + g.Type)
+	}
+	return json.Unmarshal(g.Coordinates, coordinates)
+}
+
+// lineCoordinates converts a line to a slice of coordinates.
+func lineCoordinates(l Line) [][2]float64 {
+	coordinates := make([][2]float64, len(l))
+	for i, p := range l {
+		coordinates[i] = p
+	}
+	return coordinates
+}
+
+// coordinatesLine converts a slice of coordinates to a line.
+func coordinatesLine(coordinates [][2]float64) Line {
+	l := make(Line, len(coordinates))
+	for i, c := range coordinates {
+		l[i] = c
+	}
+	return l
+}
@@ -0,0 +1,106 @@
+package cheapRuler
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPointJSON(t *testing.T) {
+	t.Log("point GeoJSON round trip is correct")
+
+	point := Point{2.344808, 48.862851}
+	data, err := json.Marshal(point)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"type":"Point","coordinates":[2.344808,48.862851]}`
+	if string(data) != expected {
+		t.Fatalf("%s != %s", data, expected)
+	}
+
+	var decoded Point
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != point {
+		t.Fatalf("%+v != %+v", decoded, point)
+	}
+
+	t.Log("OK", string(data))
+}
+
+func TestLineJSON(t *testing.T) {
+	t.Log("line GeoJSON round trip is correct")
+
+	data, err := json.Marshal(testLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Line
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(testLine) {
+		t.Fatalf("%+v != %+v", decoded, testLine)
+	}
+	for i := range testLine {
+		if decoded[i] != testLine[i] {
+			t.Fatalf("%+v != %+v", decoded, testLine)
+		}
+	}
+
+	t.Log("OK", string(data))
+}
+
+func TestPolygonJSON(t *testing.T) {
+	t.Log("polygon GeoJSON round trip is correct")
+
+	ring := append(Line{}, testLine...)
+	ring = append(ring, testLine[0])
+	polygon := Polygon{ring, Line{testLine[1], testLine[2], testLine[3], testLine[1]}}
+	data, err := json.Marshal(polygon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Polygon
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(polygon) {
+		t.Fatalf("%+v != %+v", decoded, polygon)
+	}
+	for i := range polygon {
+		if len(decoded[i]) != len(polygon[i]) {
+			t.Fatalf("%+v != %+v", decoded, polygon)
+		}
+		for j := range polygon[i] {
+			if decoded[i][j] != polygon[i][j] {
+				t.Fatalf("%+v != %+v", decoded, polygon)
+			}
+		}
+	}
+
+	t.Log("OK", string(data))
+}
+
+func TestGeometryTypeMismatch(t *testing.T) {
+	t.Log("GeoJSON geometries of the wrong type are rejected")
+
+	var line Line
+	if err := json.Unmarshal([]byte(`{"type":"Point","coordinates":[2.3,48.8]}`), &line); err == nil {
+		t.Fatalf("a Point geometry should not decode into a Line")
+	}
+
+	var point Point
+	if err := json.Unmarshal([]byte(`{"type":"LineString","coordinates":[[2.3,48.8]]}`), &point); err == nil {
+		t.Fatalf("a LineString geometry should not decode into a Point")
+	}
+
+	t.Log("OK")
+}
@@ -0,0 +1,178 @@
+package cheapRuler
+
+import (
+	"errors"
+	"math"
+)
+
+// GridIndex is a spatial index that buckets line segments into a uniform grid, to quickly find
+// the line closest to a point.
+type GridIndex struct {
+	ruler    Ruler
+	cellSize float64
+	cells    map[[2]int][]gridSegment
+	minCell  [2]int
+	maxCell  [2]int
+}
+
+// gridSegment is a segment stored in a GridIndex, along with its line id and its index in that line.
+type gridSegment struct {
+	id    int
+	index int
+	a     Point
+	b     Point
+}
+
+// NewGridIndex instantiates a new empty GridIndex from a ruler and the size of its cells in ruler units.
+// Queries are fastest when the cells are about the size of the indexed segments.
+func NewGridIndex(r Ruler, cellSize float64) (*GridIndex, error) {
+	if !(cellSize > 0) {
+		return nil, errors.New("cell size must be positive")
+	}
+	return &GridIndex{
+		ruler:    r,
+		cellSize: cellSize,
+		cells:    map[[2]int][]gridSegment{},
+	}, nil
+}
+
+// Insert adds the segments of the given line to the index under the given id. Empty lines are ignored.
+func (g *GridIndex) Insert(id int, l Line) {
+	if len(l) == 1 {
+		g.insertSegment(gridSegment{id: id, a: l[0], b: l[0]})
+	}
+	for i := 0; i < len(l)-1; i++ {
+		g.insertSegment(gridSegment{id: id, index: i, a: l[i], b: l[i+1]})
+	}
+}
+
+// Nearest snaps the given point on the closest of the indexed lines, and returns the id of that line
+// along with the PointOnLine result, whose index refers to the segment in the inserted line.
+// Ties are resolved with the lowest id, and an empty index returns an id of -1.
+func (g *GridIndex) Nearest(p Point) (int, PointOnLine) {
+	if len(g.cells) == 0 {
+		return -1, PointOnLine{index: -1}
+	}
+
+	c := g.cell(p)
+
+	// rings closer than the extent of the index are empty, and rings beyond it hold nothing new
+	minRing := maxInt(maxInt(g.minCell[0]-c[0], c[0]-g.maxCell[0]), maxInt(g.minCell[1]-c[1], c[1]-g.maxCell[1]))
+	minRing = maxInt(minRing, 0)
+	maxRing := maxInt(maxInt(c[0]-g.minCell[0], g.maxCell[0]-c[0]), maxInt(c[1]-g.minCell[1], g.maxCell[1]-c[1]))
+
+	minDist := math.Inf(1)
+	minID := -1
+	var minPol PointOnLine
+
+	visit := func(x int, y int) {
+		for _, s := range g.cells[[2]int{x, y}] {
+			pol, sqDist := g.ruler.pointOnLine(Line{s.a, s.b}, p)
+			pol.index = s.index
+			if sqDist < minDist || sqDist == minDist && (s.id < minID || s.id == minID && s.index < minPol.index) {
+				minDist = sqDist
+				minID = s.id
+				minPol = pol
+			}
+		}
+	}
+
+	for k := minRing; k <= maxRing; k++ {
+		// only the cells on the perimeter of the ring that are within the extent of the index are visited
+		minX := maxInt(c[0]-k, g.minCell[0])
+		maxX := minInt(c[0]+k, g.maxCell[0])
+		for _, y := range []int{c[1] - k, c[1] + k} {
+			if y >= g.minCell[1] && y <= g.maxCell[1] {
+				for x := minX; x <= maxX; x++ {
+					visit(x, y)
+				}
+			}
+			if k == 0 {
+				break
+			}
+		}
+
+		minY := maxInt(c[1]-k+1, g.minCell[1])
+		maxY := minInt(c[1]+k-1, g.maxCell[1])
+		for _, x := range []int{c[0] - k, c[0] + k} {
+			if k > 0 && x >= g.minCell[0] && x <= g.maxCell[0] {
+				for y := minY; y <= maxY; y++ {
+					visit(x, y)
+				}
+			}
+		}
+
+		// cells beyond the current ring are at least k cells away from the point
+		if math.Sqrt(minDist) < float64(k)*g.cellSize {
+			break
+		}
+	}
+
+	return minID, minPol
+}
+
+// insertSegment adds the segment to every cell that it crosses, walking the grid from one end to the other.
+func (g *GridIndex) insertSegment(s gridSegment) {
+	ax, ay := g.ruler.Project(s.a)
+	bx, by := g.ruler.Project(s.b)
+	ax, ay, bx, by = ax/g.cellSize, ay/g.cellSize, bx/g.cellSize, by/g.cellSize
+
+	start := g.cell(s.a)
+	end := g.cell(s.b)
+	if len(g.cells) == 0 {
+		g.minCell = start
+		g.maxCell = start
+	}
+	for _, c := range [][2]int{start, end} {
+		g.minCell = [2]int{minInt(g.minCell[0], c[0]), minInt(g.minCell[1], c[1])}
+		g.maxCell = [2]int{maxInt(g.maxCell[0], c[0]), maxInt(g.maxCell[1], c[1])}
+	}
+
+	// the distances along the segment, as a fraction of its length, to the next cell boundary and between boundaries
+	stepX, nextX, deltaX := gridStep(ax, bx)
+	stepY, nextY, deltaY := gridStep(ay, by)
+
+	x, y := start[0], start[1]
+	for x != end[0] || y != end[1] {
+		g.cells[[2]int{x, y}] = append(g.cells[[2]int{x, y}], s)
+		if y == end[1] || (x != end[0] && nextX < nextY) {
+			x += stepX
+			nextX += deltaX
+		} else {
+			y += stepY
+			nextY += deltaY
+		}
+	}
+	g.cells[end] = append(g.cells[end], s)
+}
+
+// gridStep returns the direction of a walk from a to b along one axis of the grid, in cell units,
+// the fraction of the walk at which the first cell boundary is crossed, and the fraction between two boundaries.
+func gridStep(a float64, b float64) (int, float64, float64) {
+	if b > a {
+		return 1, (math.Floor(a) + 1 - a) / (b - a), 1 / (b - a)
+	} else if b < a {
+		return -1, (a - math.Floor(a)) / (a - b), 1 / (a - b)
+	}
+	return 0, math.Inf(1), math.Inf(1)
+}
+
+// cell returns the coordinates of the grid cell containing the given point.
+func (g *GridIndex) cell(p Point) [2]int {
+	x, y := g.ruler.Project(p)
+	return [2]int{int(math.Floor(x / g.cellSize)), int(math.Floor(y / g.cellSize))}
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,100 @@
+package cheapRuler
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGridIndex(t *testing.T) {
+	t.Log("grid index is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	random := rand.New(rand.NewSource(42))
+	origin := Point{2.3469, 48.8629}
+
+	lines := make([]Line, 200)
+	for i := range lines {
+		p := ruler.Offset(origin, random.Float64()*5000, random.Float64()*5000)
+		lines[i] = Line{p}
+		for j := random.Intn(5); j >= 0; j-- {
+			p = ruler.Offset(p, random.Float64()*400-200, random.Float64()*400-200)
+			lines[i] = append(lines[i], p)
+		}
+	}
+
+	index, err := NewGridIndex(ruler, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range lines {
+		index.Insert(i, l)
+	}
+
+	for i := 0; i < 500; i++ {
+		p := ruler.Offset(origin, random.Float64()*7000-1000, random.Float64()*7000-1000)
+		id, pol := index.Nearest(p)
+		expectedID, expectedPol := ruler.NearestOnLines(lines, p)
+		if id != expectedID || pol.Index() != expectedPol.Index() {
+			t.Fatalf("%d, %d != %d, %d", id, pol.Index(), expectedID, expectedPol.Index())
+		}
+		if ruler.Distance(pol.Coordinate(), expectedPol.Coordinate()) > 1e-6 {
+			t.Fatalf("%+v != %+v", pol.Coordinate(), expectedPol.Coordinate())
+		}
+	}
+
+	fine, _ := NewGridIndex(ruler, 10)
+	fine.Insert(0, testLine)
+	for _, d := range []float64{5000, 50000} {
+		for _, bearing := range []float64{0, 135, 260} {
+			p := ruler.Destination(testLine[0], d, bearing)
+			_, pol := fine.Nearest(p)
+			if expected := ruler.PointOnLine(testLine, p); pol.Index() != expected.Index() || ruler.Distance(pol.Coordinate(), expected.Coordinate()) > 1e-6 {
+				t.Fatalf("%+v != %+v", pol, expected)
+			}
+		}
+	}
+
+	diagonal, _ := NewGridIndex(ruler, 10)
+	a := Point{2.3469, 48.8629}
+	diagonal.Insert(0, Line{a, ruler.Offset(a, 1000, 1000)})
+	if len(diagonal.cells) > 201 {
+		t.Fatalf("%d cells should be at most %d", len(diagonal.cells), 201)
+	}
+	for _, p := range []Point{ruler.Offset(a, 500, 505), ruler.Offset(a, 995, 1000), ruler.Offset(a, 3, 0)} {
+		_, pol := diagonal.Nearest(p)
+		if expected := ruler.PointOnLine(Line{a, ruler.Offset(a, 1000, 1000)}, p); ruler.Distance(pol.Coordinate(), expected.Coordinate()) > 1e-6 {
+			t.Fatalf("%+v != %+v", pol, expected)
+		}
+	}
+
+	empty, _ := NewGridIndex(ruler, 250)
+	if id, pol := empty.Nearest(origin); id != -1 || pol.Index() != -1 {
+		t.Fatalf("%d, %d != -1, -1", id, pol.Index())
+	}
+
+	if _, err := NewGridIndex(ruler, 0); err == nil {
+		t.Fatal("expected an error for a zero cell size")
+	}
+	if _, err := NewGridIndex(ruler, math.NaN()); err == nil {
+		t.Fatal("expected an error for a NaN cell size")
+	}
+
+	t.Log("OK", len(index.cells))
+}
+
+func BenchmarkGridIndexNearest(b *testing.B) {
+	ruler, _ := NewRuler(48.8629, "meters")
+	random := rand.New(rand.NewSource(42))
+	origin := Point{2.3469, 48.8629}
+
+	index, _ := NewGridIndex(ruler, 250)
+	for i := 0; i < 1000; i++ {
+		p := ruler.Offset(origin, random.Float64()*10000, random.Float64()*10000)
+		index.Insert(i, Line{p, ruler.Offset(p, random.Float64()*400-200, random.Float64()*400-200)})
+	}
+
+	for i := 0; i < b.N; i++ {
+		index.Nearest(ruler.Offset(origin, float64(i%100)*100, float64(i%37)*270))
+	}
+}
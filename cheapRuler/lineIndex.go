@@ -0,0 +1,49 @@
+package cheapRuler
+
+import (
+	"sort"
+)
+
+// LineIndex is a line with precomputed cumulative distances, to quickly find points along it.
+type LineIndex struct {
+	ruler     Ruler
+	line      Line
+	distances []float64
+}
+
+// NewLineIndex instantiates a new LineIndex from a ruler and a line, which is copied.
+func NewLineIndex(r Ruler, l Line) LineIndex {
+	return LineIndex{
+		ruler:     r,
+		line:      r.CloneLine(l),
+		distances: r.CumulativeDistances(l),
+	}
+}
+
+// At returns the point located at the given distance along the line, in ruler units, like Ruler.Along.
+// It uses a binary search, so the complexity is O(log(n)). An empty line returns a zero Point.
+func (li LineIndex) At(dist float64) Point {
+	n := len(li.line)
+	if n == 0 {
+		return Point{}
+	}
+	if dist <= 0 {
+		return li.line[0]
+	}
+
+	j := sort.Search(n, func(i int) bool { return li.distances[i] > dist })
+	if j == n {
+		return li.line[n-1]
+	}
+
+	d := li.distances[j] - li.distances[j-1]
+	return interpolateAcross(li.line[j-1], li.line[j], (dist-li.distances[j-1])/d)
+}
+
+// Length returns the total distance of the line, in ruler units.
+func (li LineIndex) Length() float64 {
+	if len(li.distances) == 0 {
+		return 0
+	}
+	return li.distances[len(li.distances)-1]
+}
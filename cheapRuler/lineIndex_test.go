@@ -0,0 +1,52 @@
+package cheapRuler
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLineIndex(t *testing.T) {
+	t.Log("line index is correct")
+
+	ruler, _ := NewRuler(48.8629, "meters")
+	index := NewLineIndex(ruler, testLine)
+
+	if math.Abs(index.Length()-ruler.LineDistance(testLine)) > 1e-9 {
+		t.Fatalf("%f != %f", index.Length(), ruler.LineDistance(testLine))
+	}
+
+	for _, dist := range []float64{-10, 0, 10, 42.5, 150, 250, 290, 1000} {
+		p := index.At(dist)
+		expected := ruler.Along(testLine, dist)
+		if math.Abs(p[0]-expected[0]) > 1e-9 || math.Abs(p[1]-expected[1]) > 1e-9 {
+			t.Fatalf("%+v != %+v", p, expected)
+		}
+	}
+
+	if p := NewLineIndex(ruler, Line{}).At(10); p != (Point{}) {
+		t.Fatalf("%+v != %+v", p, Point{})
+	}
+
+	t.Log("OK", index.Length())
+}
+
+func BenchmarkLineIndexAt(b *testing.B) {
+	ruler, _ := NewRuler(48.8629, "meters")
+	line := ruler.Densify(testLine, 1)
+	index := NewLineIndex(ruler, line)
+	length := index.Length()
+
+	for i := 0; i < b.N; i++ {
+		index.At(float64(i%100) / 100 * length)
+	}
+}
+
+func BenchmarkAlong(b *testing.B) {
+	ruler, _ := NewRuler(48.8629, "meters")
+	line := ruler.Densify(testLine, 1)
+	length := ruler.LineDistance(line)
+
+	for i := 0; i < b.N; i++ {
+		ruler.Along(line, float64(i%100)/100*length)
+	}
+}
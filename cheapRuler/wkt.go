@@ -0,0 +1,130 @@
+package cheapRuler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ToWKT returns the Well-Known Text representation of the point.
+func (p Point) ToWKT() string {
+	return "POINT (" + formatWKTCoordinates(Line{p}) + ")"
+}
+
+// ToWKT returns the Well-Known Text representation of the line, as a LINESTRING.
+func (l Line) ToWKT() string {
+	return "LINESTRING (" + formatWKTCoordinates(l) + ")"
+}
+
+// ToWKT returns the Well-Known Text representation of the polygon.
+func (p Polygon) ToWKT() string {
+	rings := make([]string, len(p))
+	for i, ring := range p {
+		rings[i] = "(" + formatWKTCoordinates(ring) + ")"
+	}
+	return "POLYGON (" + strings.Join(rings, ", ") + ")"
+}
+
+// ParseWKTPoint parses a Well-Known Text POINT into a point.
+func ParseWKTPoint(s string) (Point, error) {
+	body, err := wktBody(s, "POINT")
+	if err != nil {
+		return Point{}, err
+	}
+	coordinates, err := parseWKTCoordinates(body)
+	if err != nil {
+		return Point{}, err
+	}
+	if len(coordinates) != 1 {
+		return Point{}, errors.New("WKT POINT must have exactly one coordinate")
+	}
+	return coordinates[0], nil
+}
+
+// ParseWKTLineString parses a Well-Known Text LINESTRING into a line.
+func ParseWKTLineString(s string) (Line, error) {
+	body, err := wktBody(s, "LINESTRING")
+	if err != nil {
+		return nil, err
+	}
+	return parseWKTCoordinates(body)
+}
+
+// ParseWKTPolygon parses a Well-Known Text POLYGON into a polygon.
+func ParseWKTPolygon(s string) (Polygon, error) {
+	body, err := wktBody(s, "POLYGON")
+	if err != nil {
+		return nil, err
+	}
+
+	var polygon Polygon
+	for {
+		body = strings.TrimSpace(body)
+		if !strings.HasPrefix(body, "(") {
+			return nil, errors.New("WKT POLYGON ring must start with (")
+		}
+		end := strings.Index(body, ")")
+		if end < 0 {
+			return nil, errors.New("WKT POLYGON ring must end with )")
+		}
+		ring, err := parseWKTCoordinates(body[1:end])
+		if err != nil {
+			return nil, err
+		}
+		polygon = append(polygon, ring)
+
+		body = strings.TrimSpace(body[end+1:])
+		if body == "" {
+			return polygon, nil
+		}
+		if !strings.HasPrefix(body, ",") {
+			return nil, errors.New("WKT POLYGON rings must be separated by commas")
+		}
+		body = body[1:]
+	}
+}
+
+// wktBody checks that the given Well-Known Text is of the given geometry type,
+// and returns the content between its outer parentheses.
+func wktBody(s string, geometryType string) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < len(geometryType) || !strings.EqualFold(s[:len(geometryType)], geometryType) {
+		return "", errors.New("expected a WKT " + geometryType + ": " + s)
+	}
+
+	s = strings.TrimSpace(s[len(geometryType):])
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return "", errors.New("WKT " + geometryType + " coordinates must be enclosed in parentheses")
+	}
+	return s[1 : len(s)-1], nil
+}
+
+// parseWKTCoordinates parses comma separated "lon lat" Well-Known Text coordinates.
+func parseWKTCoordinates(s string) (Line, error) {
+	var l Line
+	for _, c := range strings.Split(s, ",") {
+		fields := strings.Fields(c)
+		if len(fields) != 2 {
+			return nil, errors.New("WKT coordinate must have a longitude and a latitude: " + strings.TrimSpace(c))
+		}
+		lon, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		lat, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, Point{lon, lat})
+	}
+	return l, nil
+}
+
+// formatWKTCoordinates formats points as comma separated "lon lat" Well-Known Text coordinates.
+func formatWKTCoordinates(l Line) string {
+	coordinates := make([]string, len(l))
+	for i, p := range l {
+		coordinates[i] = strconv.FormatFloat(p[0], 'f', -1, 64) + " " + strconv.FormatFloat(p[1], 'f', -1, 64)
+	}
+	return strings.Join(coordinates, ", ")
+}
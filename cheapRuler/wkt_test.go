@@ -0,0 +1,112 @@
+package cheapRuler
+
+import (
+	"testing"
+)
+
+func TestPointWKT(t *testing.T) {
+	t.Log("point WKT round trip is correct")
+
+	point := Point{2.344808, 48.862851}
+	wkt := point.ToWKT()
+	expected := "POINT (2.344808 48.862851)"
+
+	if wkt != expected {
+		t.Fatalf("%s != %s", wkt, expected)
+	}
+
+	decoded, err := ParseWKTPoint(wkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != point {
+		t.Fatalf("%+v != %+v", decoded, point)
+	}
+
+	t.Log("OK", wkt)
+}
+
+func TestLineStringWKT(t *testing.T) {
+	t.Log("line WKT round trip is correct")
+
+	wkt := testLine.ToWKT()
+	decoded, err := ParseWKTLineString(wkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(testLine) {
+		t.Fatalf("%+v != %+v", decoded, testLine)
+	}
+	for i := range testLine {
+		if decoded[i] != testLine[i] {
+			t.Fatalf("%+v != %+v", decoded, testLine)
+		}
+	}
+
+	t.Log("OK", wkt)
+}
+
+func TestPolygonWKT(t *testing.T) {
+	t.Log("polygon WKT round trip is correct")
+
+	polygon := Polygon{
+		Line{Point{2.350, 48.862}, Point{2.354, 48.862}, Point{2.354, 48.866}, Point{2.350, 48.862}},
+		Line{Point{2.351, 48.863}, Point{2.352, 48.863}, Point{2.352, 48.864}, Point{2.351, 48.863}},
+	}
+	wkt := polygon.ToWKT()
+	expected := "POLYGON ((2.35 48.862, 2.354 48.862, 2.354 48.866, 2.35 48.862), " +
+		"(2.351 48.863, 2.352 48.863, 2.352 48.864, 2.351 48.863))"
+
+	if wkt != expected {
+		t.Fatalf("%s != %s", wkt, expected)
+	}
+
+	decoded, err := ParseWKTPolygon(wkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(polygon) {
+		t.Fatalf("%+v != %+v", decoded, polygon)
+	}
+	for i := range polygon {
+		if len(decoded[i]) != len(polygon[i]) {
+			t.Fatalf("%+v != %+v", decoded, polygon)
+		}
+		for j := range polygon[i] {
+			if decoded[i][j] != polygon[i][j] {
+				t.Fatalf("%+v != %+v", decoded, polygon)
+			}
+		}
+	}
+
+	t.Log("OK", wkt)
+}
+
+func TestMalformedWKT(t *testing.T) {
+	t.Log("malformed WKT is rejected")
+
+	if _, err := ParseWKTPoint("LINESTRING (1 2, 3 4)"); err == nil {
+		t.Fatalf("a LINESTRING should not parse as a POINT")
+	}
+
+	if _, err := ParseWKTPoint("POINT (1 2 3)"); err == nil {
+		t.Fatalf("a coordinate with three values should not parse")
+	}
+
+	if _, err := ParseWKTLineString("LINESTRING (1 2, 3 x)"); err == nil {
+		t.Fatalf("a non numeric coordinate should not parse")
+	}
+
+	if _, err := ParseWKTLineString("LINESTRING 1 2, 3 4"); err == nil {
+		t.Fatalf("coordinates without parentheses should not parse")
+	}
+
+	if _, err := ParseWKTPolygon("POLYGON ((1 2, 3 4, 5 6, 1 2) (1 2, 3 4, 5 6, 1 2))"); err == nil {
+		t.Fatalf("rings without a separating comma should not parse")
+	}
+
+	t.Log("OK")
+}